@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -11,87 +12,236 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/gorankrgovic/dai/internal/config"
+	"github.com/gorankrgovic/dai/internal/gh"
+	"github.com/gorankrgovic/dai/internal/project"
+	"github.com/gorankrgovic/dai/internal/tracker"
 )
 
 var (
-	flagAuthToken  string
-	flagAuthShow   bool
-	flagAuthDelete bool
+	flagAuthToken          string
+	flagAuthShow           bool
+	flagAuthDelete         bool
+	flagAuthProvider       string
+	flagAuthHost           string
+	flagAuthVerify         bool
+	flagAuthAppID          string
+	flagAuthPrivateKey     string
+	flagAuthInstallationID int64
 )
 
-// authCmd handles authentication-related actions (currently GitHub PAT).
+// Services dai stores credentials for besides the tracker providers.
+const (
+	// providerJira names the Jira token, which is not an issue tracker
+	// backend of its own.
+	providerJira = "jira"
+	// serviceGitHubEnterprise is GitHub on a host of its own (--host).
+	serviceGitHubEnterprise = "github-enterprise"
+	serviceOpenAI           = "openai"
+	serviceAnthropic        = "anthropic"
+)
+
+// authServices are the services dai stores credentials for, with their
+// display names.
+var authServices = map[string]string{
+	tracker.GitHub:          "GitHub",
+	serviceGitHubEnterprise: "GitHub Enterprise",
+	tracker.GitLab:          "GitLab",
+	tracker.Gitea:           "Gitea/Forgejo",
+	providerJira:            "Jira",
+	serviceOpenAI:           "OpenAI",
+	serviceAnthropic:        "Anthropic",
+}
+
+// authServiceOrder is the order status lists services in.
+var authServiceOrder = []string{tracker.GitHub, tracker.GitLab, tracker.Gitea, providerJira, serviceOpenAI, serviceAnthropic}
+
+// authService checks a service name from the command line and maps
+// github-enterprise to github on its host.
+func authService(service, host string) (string, string, error) {
+	service = strings.ToLower(strings.TrimSpace(service))
+	if service == "" {
+		service = tracker.GitHub
+	}
+	if service == tracker.Forgejo {
+		service = tracker.Gitea
+	}
+	if _, ok := authServices[service]; !ok {
+		return "", "", fmt.Errorf("unknown service %q (use github, github-enterprise, gitlab, gitea, jira, openai or anthropic)", service)
+	}
+	if service == serviceGitHubEnterprise {
+		if host == "" {
+			return "", "", errors.New("github-enterprise needs --host")
+		}
+		service = tracker.GitHub
+	}
+	return service, strings.ToLower(strings.TrimSpace(host)), nil
+}
+
+// serviceName is the display name of service on host.
+func serviceName(service, host string) string {
+	name := authServices[service]
+	if host != "" {
+		name += " (" + host + ")"
+	}
+	return name
+}
+
+// authCmd handles authentication-related actions (GitHub, GitLab and Gitea tokens).
 var authCmd = &cobra.Command{
 	Use:   "auth",
-	Short: "Authenticate DAI with external services (GitHub token for now)",
+	Short: "Authenticate DAI with external services (GitHub, GitLab, Gitea, Jira, OpenAI, Anthropic)",
 	Long: `Authenticate DAI with external services.
 
-This stores your GitHub Personal Access Token (PAT) in ~/.dai/github_token with 0600 permissions.
+Credentials are kept per service and host in ~/.dai/credentials.yaml (0600),
+with the secrets themselves in the store chosen by 'dai config set-store'.
+A credential without a host is the service's default. It is only used for
+the service's own host (github.com, gitlab.com, codeberg.org); any other
+host, including one named in a project's .dai/project.yaml, needs a
+credential stored for exactly that host.
+
+Without a subcommand this behaves like 'dai auth login': it stores a GitHub
+Personal Access Token, or with --provider gitlab a GitLab personal or project
+access token (api scope), --provider gitea a Gitea/Forgejo access token
+(issue and repository write scopes) and --provider jira "email:api-token"
+(Jira Cloud) or a personal access token (Server/Data Center).
+
+With --app-id and --private-key dai authenticates as a GitHub App instead:
+issues, checks and reviews are then created by the app's bot account with
+short-lived installation tokens, which are cached in ~/.dai and refreshed
+before they expire.
 It is separate from 'dai config' on purpose (future: cloud/local modes).`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		service, host, err := authService(flagAuthProvider, flagAuthHost)
+		if err != nil {
+			return err
+		}
 		// Default behaviour when no subcommand is provided
 		if flagAuthShow {
-			return runAuthShow()
+			return runAuthShow(cmd.Context(), service, host)
 		}
 		if flagAuthDelete {
-			return runAuthDelete()
+			return runAuthDelete(cmd.Context(), service, host)
+		}
+		return runAuthLogin(cmd.Context(), service, host)
+	},
+}
+
+var authLoginCmd = &cobra.Command{
+	Use:   "login [service]",
+	Short: "Store a credential for a service (default github), optionally for one --host",
+	Example: `  dai auth login
+  dai auth login github-enterprise --host git.corp.example
+  dai auth login gitlab --host gitlab.example.com --token "$TOKEN"
+  dai auth login openai`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		service, host, err := authService(firstArg(args), flagAuthHost)
+		if err != nil {
+			return err
 		}
-		return runAuthInteractive()
+		return runAuthLogin(cmd.Context(), service, host)
 	},
 }
 
-var authStatusCmd = &cobra.Command{
-	Use:   "status",
-	Short: "Show whether a GitHub token is stored",
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout [service]",
+	Short: "Delete the stored credential for a service (default github) and --host",
+	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runAuthShow()
+		service, host, err := authService(firstArg(args), flagAuthHost)
+		if err != nil {
+			return err
+		}
+		return runAuthDelete(cmd.Context(), service, host)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(authCmd)
-	authCmd.Flags().StringVar(&flagAuthToken, "token", "", "GitHub Personal Access Token (non-interactive)")
-	authCmd.Flags().BoolVar(&flagAuthShow, "show", false, "Show whether a token is stored (does not print the token)")
-	authCmd.Flags().BoolVar(&flagAuthDelete, "delete", false, "Delete stored GitHub token")
+	authCmd.Flags().StringVar(&flagAuthToken, "token", "", "Access token (non-interactive)")
+	authCmd.Flags().BoolVar(&flagAuthShow, "show", false, "Show whether a token is found and where (does not print the token)")
+	authCmd.Flags().BoolVar(&flagAuthDelete, "delete", false, "Delete stored token")
+	authCmd.Flags().StringVar(&flagAuthProvider, "provider", tracker.GitHub, "Service the token is for: github, gitlab, gitea, jira, openai or anthropic")
+	authCmd.Flags().StringVar(&flagAuthHost, "host", "", "Host the token is for (default: the service's own host)")
 
-	// Add subcommand: dai auth status
+	for _, c := range []*cobra.Command{authCmd, authLoginCmd} {
+		c.Flags().StringVar(&flagAuthAppID, "app-id", "", "Authenticate as this GitHub App instead of with a token")
+		c.Flags().StringVar(&flagAuthPrivateKey, "private-key", "", "Path to the GitHub App's PEM private key (with --app-id)")
+		c.Flags().Int64Var(&flagAuthInstallationID, "installation-id", 0, "GitHub App installation to use (default: looked up per repository)")
+	}
+	authLoginCmd.Flags().StringVar(&flagAuthToken, "token", "", "Access token (non-interactive)")
+	authLoginCmd.Flags().StringVar(&flagAuthHost, "host", "", "Host the token is for (default: the service's own host)")
+	authLogoutCmd.Flags().StringVar(&flagAuthHost, "host", "", "Host whose credential to delete (default: the service default)")
+	authStatusCmd.Flags().BoolVar(&flagAuthVerify, "verify", false, "Check each credential against the service and show its user, scopes and expiry")
+
+	authCmd.AddCommand(authLoginCmd)
+	authCmd.AddCommand(authLogoutCmd)
+	authCmd.AddCommand(authListCmd)
 	authCmd.AddCommand(authStatusCmd)
 }
 
-func runAuthShow() error {
-	exists, err := config.GitHubTokenExists()
-	if err != nil {
-		return err
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
 	}
-	if exists {
-		fmt.Println("✓ GitHub token is stored (in ~/.dai/github_token).")
-	} else {
-		fmt.Println("✗ No GitHub token stored.")
+	return args[0]
+}
+
+// authProject is the project in the working directory when it uses
+// provider, for the host to look credentials up for.
+func authProject(provider string) *project.Project {
+	if wd, err := os.Getwd(); err == nil {
+		if p, err := project.Load(wd); err == nil && (p.Provider == provider || p.Provider == "" && provider == tracker.GitHub) {
+			return p
+		}
 	}
-	return nil
+	return &project.Project{Provider: provider}
 }
 
-func runAuthDelete() error {
-	if err := confirmDanger("This will delete the stored GitHub token. Continue?"); err != nil {
+func runAuthDelete(ctx context.Context, service, host string) error {
+	name := serviceName(service, host)
+	if service == tracker.GitHub && host == "" && config.GitHubAppConfigured() {
+		if err := confirmDanger("This will delete the stored GitHub App config and private key. Continue?"); err != nil {
+			fmt.Println("Kept the GitHub App.")
+		} else if err := config.DeleteGitHubApp(); err != nil {
+			return err
+		} else {
+			fmt.Println("Deleted the GitHub App config.")
+		}
+	}
+	if _, err := config.FindCredential(service, host); errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("No stored %s credential to delete.\n", name)
+		return nil
+	}
+	if err := confirmDanger(fmt.Sprintf("This will delete the stored %s token. Continue?", name)); err != nil {
 		fmt.Println("Aborted.")
 		return nil
 	}
-	if err := config.DeleteGitHubToken(); err != nil {
+	if err := config.DeleteCredential(ctx, service, host); err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			fmt.Println("No stored token to delete.")
+			// only a default credential, which applies to host as well
+			fmt.Printf("No %s credential stored for exactly that host; the default is kept (logout without --host to delete it).\n", authServices[service])
 			return nil
 		}
 		return err
 	}
-	fmt.Println("Deleted stored GitHub token.")
+	fmt.Printf("Deleted stored %s token.\n", name)
 	return nil
 }
 
-func runAuthInteractive() error {
+func runAuthLogin(ctx context.Context, service, host string) error {
+	if flagAuthAppID != "" {
+		if service != tracker.GitHub {
+			return fmt.Errorf("--app-id only applies to github")
+		}
+		return runAuthApp(ctx)
+	}
+	name := serviceName(service, host)
 	token := strings.TrimSpace(flagAuthToken)
 	if token == "" {
 		var input string
 		q := &survey.Password{
-			Message: "Paste your GitHub Personal Access Token (will be hidden):",
+			Message: fmt.Sprintf("Paste your %s access token (will be hidden):", name),
 		}
 		if err := survey.AskOne(q, &input, survey.WithValidator(survey.Required)); err != nil {
 			return err
@@ -99,20 +249,74 @@ func runAuthInteractive() error {
 		token = strings.TrimSpace(input)
 	}
 
-	if err := validateGitHubToken(token); err != nil {
+	validate := validateGitHubToken
+	switch service {
+	case tracker.GitLab:
+		validate = validateGitLabToken
+	case tracker.Gitea:
+		validate = validateGiteaToken
+	case providerJira:
+		validate = validateJiraToken
+	case serviceOpenAI:
+		validate = validateOpenAIKey
+	case serviceAnthropic:
+		validate = validateAnthropicKey
+	}
+	if err := validate(token); err != nil {
 		return err
 	}
 
-	if err := confirm("Save token to ~/.dai/github_token?"); err != nil {
+	if err := confirm(fmt.Sprintf("Save the %s token to ~/.dai/credentials.yaml?", name)); err != nil {
 		fmt.Println("Aborted.")
 		return nil
 	}
 
-	if err := config.SaveGitHubToken(token); err != nil {
+	if err := config.SaveCredential(ctx, service, host, token); err != nil {
 		return err
 	}
 
-	fmt.Println("✓ GitHub token saved to ~/.dai/github_token (permissions 0600).")
+	fmt.Printf("✓ %s token saved to ~/.dai/credentials.yaml (permissions 0600).\n", name)
+	return nil
+}
+
+// runAuthApp checks the GitHub App credentials against the API and stores
+// them. Inside a DAI project it also checks the app is installed there.
+func runAuthApp(ctx context.Context) error {
+	if flagAuthPrivateKey == "" {
+		return errors.New("--app-id requires --private-key")
+	}
+	pem, err := os.ReadFile(flagAuthPrivateKey)
+	if err != nil {
+		return fmt.Errorf("read private key: %w", err)
+	}
+	key, err := gh.ParsePrivateKey(pem)
+	if err != nil {
+		return err
+	}
+
+	prj := authProject(tracker.GitHub)
+	src := gh.NewAppTokenSource(apiBase(prj), flagAuthAppID, key, flagAuthInstallationID, prj.Owner, prj.Repo)
+	app, err := src.App(ctx)
+	if err != nil {
+		return fmt.Errorf("verify GitHub App %s: %w", flagAuthAppID, err)
+	}
+	fmt.Printf("✓ Authenticated as GitHub App %q; DAI will act as %s.\n", app.Name, app.BotLogin())
+	if prj.Owner != "" || flagAuthInstallationID > 0 {
+		if _, err := src.Token(ctx); err != nil {
+			return err
+		}
+		fmt.Println("✓ Installation token issued.")
+	}
+
+	if err := confirm("Save the GitHub App config and a copy of the private key to ~/.dai?"); err != nil {
+		fmt.Println("Aborted.")
+		return nil
+	}
+	cfg := config.GitHubApp{AppID: flagAuthAppID, InstallationID: flagAuthInstallationID}
+	if err := config.SaveGitHubApp(cfg, pem); err != nil {
+		return err
+	}
+	fmt.Println("✓ GitHub App saved to ~/.dai/github_app.yaml (permissions 0600).")
 	return nil
 }
 
@@ -133,6 +337,74 @@ func validateGitHubToken(tok string) error {
 	return nil
 }
 
+func validateGitLabToken(tok string) error {
+	if tok == "" {
+		return errors.New("empty token")
+	}
+	if len(tok) < 20 {
+		return errors.New("token looks too short")
+	}
+	if strings.ContainsAny(tok, " \t\r\n") {
+		return errors.New("token must not contain whitespace")
+	}
+	if !strings.HasPrefix(tok, "glpat-") {
+		fmt.Println("! Note: token doesn't match the typical GitLab prefix (glpat-). Continuing anyway.")
+	}
+	return nil
+}
+
+func validateGiteaToken(tok string) error {
+	if tok == "" {
+		return errors.New("empty token")
+	}
+	if strings.ContainsAny(tok, " \t\r\n") {
+		return errors.New("token must not contain whitespace")
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{40}$`).MatchString(tok) {
+		fmt.Println("! Note: token doesn't look like a Gitea/Forgejo access token (40 hex characters). Continuing anyway.")
+	}
+	return nil
+}
+
+func validateJiraToken(tok string) error {
+	if tok == "" {
+		return errors.New("empty token")
+	}
+	if strings.ContainsAny(tok, " \t\r\n") {
+		return errors.New("token must not contain whitespace")
+	}
+	if !strings.Contains(tok, ":") {
+		fmt.Println("! Note: no 'email:' prefix; the token will be sent as a Server/Data Center personal access token. Jira Cloud needs email:api-token.")
+	}
+	return nil
+}
+
+func validateOpenAIKey(key string) error {
+	if key == "" {
+		return errors.New("empty key")
+	}
+	if strings.ContainsAny(key, " \t\r\n") {
+		return errors.New("key must not contain whitespace")
+	}
+	if !strings.HasPrefix(key, "sk-") {
+		fmt.Println("! Note: key doesn't match the typical OpenAI prefix (sk-). Continuing anyway.")
+	}
+	return nil
+}
+
+func validateAnthropicKey(key string) error {
+	if key == "" {
+		return errors.New("empty key")
+	}
+	if strings.ContainsAny(key, " \t\r\n") {
+		return errors.New("key must not contain whitespace")
+	}
+	if !strings.HasPrefix(key, "sk-ant-") {
+		fmt.Println("! Note: key doesn't match the typical Anthropic prefix (sk-ant-). Continuing anyway.")
+	}
+	return nil
+}
+
 func confirm(msg string) error {
 	var ok bool
 	p := &survey.Confirm{
@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gorankrgovic/dai/internal/config"
+	"github.com/gorankrgovic/dai/internal/credential"
+	"github.com/gorankrgovic/dai/internal/gh"
+	"github.com/gorankrgovic/dai/internal/gitea"
+	"github.com/gorankrgovic/dai/internal/gitlab"
+	"github.com/gorankrgovic/dai/internal/jira"
+	"github.com/gorankrgovic/dai/internal/project"
+	"github.com/gorankrgovic/dai/internal/secret"
+	"github.com/gorankrgovic/dai/internal/tracker"
+)
+
+var authListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the stored credentials (service, host, store)",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		creds, err := config.ListCredentials()
+		if err != nil {
+			return err
+		}
+		if len(creds) == 0 {
+			fmt.Println("No stored credentials. Add one with 'dai auth login'.")
+		} else {
+			tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "SERVICE\tHOST\tSTORE\tADDED")
+			for _, c := range creds {
+				service := c.Service
+				if service == tracker.GitHub && c.Host != "" && c.Host != "github.com" {
+					service = serviceGitHubEnterprise
+				}
+				host, added := c.Host, "-"
+				if host == "" {
+					host = "(default)"
+				}
+				if !c.Added.IsZero() {
+					added = c.Added.Local().Format("2006-01-02")
+				}
+				store := secret.Backend(c.Secret)
+				if c.Legacy {
+					store += " (~/.dai/" + c.Service + "_token)"
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", service, host, store, added)
+			}
+			tw.Flush()
+		}
+		if app, err := config.LoadGitHubApp(); err == nil {
+			fmt.Printf("GitHub App %s (~/.dai/github_app.yaml)\n", app.AppID)
+		}
+		return nil
+	},
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which credentials are found and where they come from",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		shown := map[string]bool{}
+		for _, s := range authServiceOrder {
+			host := authHost(s)
+			shown[s+"@"+host] = true
+			if err := runAuthShow(ctx, s, host); err != nil {
+				return err
+			}
+		}
+		// credentials for hosts other than the current project's
+		creds, err := config.ListCredentials()
+		if err != nil {
+			return err
+		}
+		for _, c := range creds {
+			if c.Host == "" || shown[c.Service+"@"+c.Host] {
+				continue
+			}
+			if err := runAuthShow(ctx, c.Service, c.Host); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// authHost is the host credentials for service are looked up for: the
+// current project's, or none (the service default).
+func authHost(service string) string {
+	if service == providerJira {
+		if wd, err := os.Getwd(); err == nil {
+			if p, err := project.Load(wd); err == nil && p.Jira != nil {
+				return urlHost(p.Jira.URL)
+			}
+		}
+		return ""
+	}
+	if prj := authProject(service); prj.Owner != "" {
+		return prj.APIHost()
+	}
+	return ""
+}
+
+func urlHost(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// runAuthShow prints where the credential for service on host comes from
+// and, with --verify, what the service says about it.
+func runAuthShow(ctx context.Context, service, host string) error {
+	name := serviceName(service, host)
+	c, err := resolveToken(ctx, service, host)
+	if err != nil && service == serviceOpenAI {
+		// the key may also be in config.yaml
+		if _, src, cerr := openAIConfig(""); cerr == nil {
+			c, err = src, nil
+		}
+	}
+	if err != nil {
+		fmt.Printf("✗ No %s credential found.\n", name)
+	} else {
+		fmt.Printf("✓ %s: from %s.\n", name, c.Source)
+		if flagAuthVerify {
+			info, verr := verifyCredential(ctx, service, host, c.Secret)
+			if verr != nil {
+				fmt.Printf("    ✗ %v\n", verr)
+			} else {
+				fmt.Printf("    %s\n", info)
+			}
+		}
+	}
+	if service == tracker.GitHub && (host == "" || host == authHost(tracker.GitHub)) {
+		app, err := config.LoadGitHubApp()
+		switch {
+		case err == nil:
+			fmt.Printf("✓ GitHub App %s is configured (takes precedence over the token).\n", app.AppID)
+			if flagAuthVerify {
+				fmt.Printf("    %s\n", verifyGitHubApp(ctx, app))
+			}
+		case !errors.Is(err, os.ErrNotExist):
+			return fmt.Errorf("load GitHub App config: %w", err)
+		}
+	}
+	return nil
+}
+
+// verifyCredential asks the service who token belongs to.
+func verifyCredential(ctx context.Context, service, host, token string) (string, error) {
+	switch service {
+	case tracker.GitHub:
+		info, err := gh.NewClient(verifyAPIBase(service, host), token).WhoAmI(ctx)
+		if err != nil {
+			return "", err
+		}
+		return describeToken("logged in as "+info.Login, info.Scopes, info.Expires), nil
+	case tracker.GitLab:
+		info, err := gitlab.NewClient(verifyAPIBase(service, host), token).WhoAmI(ctx)
+		if err != nil {
+			return "", err
+		}
+		who := "logged in as " + info.Username
+		if info.Name != "" {
+			who += " with token " + fmt.Sprintf("%q", info.Name)
+		}
+		return describeToken(who, info.Scopes, info.Expires), nil
+	case tracker.Gitea:
+		if host == "" {
+			return "", errors.New("not verified: no host (run inside a Gitea project or use --host)")
+		}
+		login, err := gitea.NewClient(verifyAPIBase(service, host), token).WhoAmI(ctx)
+		if err != nil {
+			return "", err
+		}
+		return "logged in as " + login, nil
+	case providerJira:
+		if host == "" {
+			return "", errors.New("not verified: no Jira site (configure jira.url in project.yaml or use --host)")
+		}
+		me, err := jira.NewClient("https://"+host, token).Myself(ctx)
+		if err != nil {
+			return "", err
+		}
+		return "logged in as " + me, nil
+	case serviceOpenAI:
+		if err := credential.VerifyOpenAI(ctx, token); err != nil {
+			return "", err
+		}
+		return "key accepted by the OpenAI API", nil
+	case serviceAnthropic:
+		if err := credential.VerifyAnthropic(ctx, token); err != nil {
+			return "", err
+		}
+		return "key accepted by the Anthropic API", nil
+	}
+	return "", fmt.Errorf("cannot verify %s credentials", service)
+}
+
+// verifyAPIBase is the API root of provider on host, honouring the current
+// project's api_url when it is for the same host.
+func verifyAPIBase(provider, host string) string {
+	if prj := authProject(provider); prj.Owner != "" && (host == "" || host == prj.APIHost()) {
+		return apiBase(prj)
+	}
+	return tracker.APIBase(provider, host)
+}
+
+func verifyGitHubApp(ctx context.Context, app *config.GitHubApp) string {
+	pem, err := os.ReadFile(app.PrivateKeyPath)
+	if err != nil {
+		return "✗ " + err.Error()
+	}
+	key, err := gh.ParsePrivateKey(pem)
+	if err != nil {
+		return "✗ " + err.Error()
+	}
+	a, err := gh.NewAppTokenSource(verifyAPIBase(tracker.GitHub, ""), app.AppID, key, 0, "", "").App(ctx)
+	if err != nil {
+		return "✗ " + err.Error()
+	}
+	return fmt.Sprintf("app %q, acting as %s", a.Name, a.BotLogin())
+}
+
+func describeToken(who string, scopes []string, expires time.Time) string {
+	parts := []string{who}
+	if len(scopes) > 0 {
+		parts = append(parts, "scopes: "+strings.Join(scopes, ", "))
+	}
+	switch {
+	case expires.IsZero():
+		parts = append(parts, "no expiry")
+	case expires.Before(time.Now()):
+		parts = append(parts, "EXPIRED "+expires.Format("2006-01-02"))
+	default:
+		parts = append(parts, "expires "+expires.Format("2006-01-02"))
+	}
+	return strings.Join(parts, "; ")
+}
@@ -1,28 +1,99 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/gorankrgovic/dai/internal/triage"
+)
+
+var (
+	flagAutofixDryRun bool
+	flagAutofixModel  string
+	flagAutofixBase   string
+	flagAutofixMaxKB  int
+	flagAutofixNoVal  bool
 )
 
 func init() {
 	rootCmd.AddCommand(autofixCmd)
+
+	autofixCmd.Flags().BoolVar(&flagAutofixDryRun, "dry-run", false, "Print the patch instead of pushing a branch and opening a PR")
+	autofixCmd.Flags().StringVar(&flagAutofixModel, "model", "", "Override OpenAI model from config (optional)")
+	autofixCmd.Flags().StringVar(&flagAutofixBase, "base", "", "Branch to fix and open the PR against (default: the remote's default branch)")
+	autofixCmd.Flags().IntVar(&flagAutofixMaxKB, "max-file-kb", 512, "Max size of a file the fix may touch (KB)")
+	autofixCmd.Flags().BoolVar(&flagAutofixNoVal, "no-validate", false, "Skip the validation command from .dai/project.yaml")
 }
 
 var autofixCmd = &cobra.Command{
-	Use:   "autofix [issue_number]",
-	Short: "Auto-fix an Issue by opening a PR (in development)",
-	Long: `🚧 This command is currently in development (TODO).
-
-Planned flow:
-- Read the GitHub Issue
-- Analyze diff/context with LLM
-- Generate a patch
-- Create a branch and open a PR`,
-	Args: cobra.MaximumNArgs(1),
+	Use:   "autofix <issue_number>",
+	Short: "Propose a fix for a DAI issue and open a PR",
+	Long: `Read the findings of an issue DAI created, ask the model for the smallest
+patch that fixes them, and apply it in a throwaway worktree of the base
+branch. The patch is committed on a new branch dai/autofix-<n>, pushed to
+origin, and a pull request (merge request on GitLab) that closes the issue
+is opened. Your checkout is not touched.
+
+validate.command from .dai/project.yaml runs in the patched worktree
+first; a fix that fails it is neither printed nor pushed, and the output is
+attached to the pull request as evidence. Without a validate.command
+nothing is pushed unless --no-validate is given.
+
+With --dry-run the patch is printed and nothing is pushed.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("🚧 'dai autofix' is in development. No changes were made.")
+		number, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+		if err != nil || number <= 0 {
+			return fmt.Errorf("invalid issue number %q", args[0])
+		}
+		wd, err := ensureProjectRoot()
+		if err != nil {
+			return err
+		}
+		prj, err := loadProject(wd)
+		if err != nil {
+			return err
+		}
+		if prj.Validate == nil && !flagAutofixNoVal && !flagAutofixDryRun {
+			return errors.New("no validate.command in .dai/project.yaml; configure one, or pass --no-validate to push an untested fix")
+		}
+		opts, err := triageOptions(cmd.Context(), wd, prj, flagAutofixModel)
+		if err != nil {
+			return err
+		}
+		opts.DryRun = flagAutofixDryRun
+		opts.MaxFileBytes = int64(flagAutofixMaxKB) * 1024
+		if !flagAutofixNoVal {
+			opts.Validate = prj.Validate
+		}
+
+		af, err := triage.RunAutofix(cmd.Context(), opts, number, flagAutofixBase)
+		if errors.Is(err, triage.ErrValidationFailed) {
+			fmt.Println(strings.TrimRight(af.Validation.Output, "\n"))
+			fmt.Println("The fix was discarded.")
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if af.Summary != "" {
+			fmt.Println(safeLine(af.Summary))
+		}
+		if af.Validation != nil {
+			fmt.Println("✓ Validation:", af.Validation.Summary())
+		} else {
+			fmt.Println("⚠ The fix was not validated; it was not built or tested.")
+		}
+		if opts.DryRun {
+			fmt.Println("— DRY RUN —")
+			fmt.Print(af.Patch)
+			return nil
+		}
+		fmt.Printf("Pushed %s (%.8s) and opened %s\n", af.Branch, af.Commit, af.URL)
 		return nil
 	},
 }
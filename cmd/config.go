@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
@@ -10,6 +12,7 @@ import (
 	survey "github.com/AlecAivazis/survey/v2"
 
 	"github.com/gorankrgovic/dai/internal/config"
+	"github.com/gorankrgovic/dai/internal/secret"
 )
 
 var modelChoices = []string{
@@ -26,12 +29,12 @@ var configWizardCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// 1) Key
 		fmt.Print("Enter your OpenAI API key (input hidden): ")
-		secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+		key, err := term.ReadPassword(int(os.Stdin.Fd()))
 		fmt.Println()
 		if err != nil {
 			return err
 		}
-		if len(secret) == 0 {
+		if len(key) == 0 {
 			return fmt.Errorf("empty key")
 		}
 
@@ -58,11 +61,12 @@ var configWizardCmd = &cobra.Command{
 			sel = custom
 		}
 
-		cfg := &config.Config{
-			OpenAIKey: string(secret),
-			Model:     sel,
+		cfg, _ := config.Read()
+		if cfg == nil {
+			cfg = &config.Config{}
 		}
-		if err := config.Save(cfg); err != nil {
+		cfg.Model = sel
+		if err := cfg.SetOpenAIKey(cmd.Context(), string(key)); err != nil {
 			return err
 		}
 		fmt.Println("Config saved.")
@@ -76,6 +80,42 @@ func init() {
 	configCmd.AddCommand(configSetKeyCmd)
 	configCmd.AddCommand(configSetModelCmd)
 	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configSetStoreCmd)
+
+	secret.Passphrase = promptPassphrase
+}
+
+// promptPassphrase asks for the passphrase of the encrypted secret store
+// on the terminal, unless DAI_PASSPHRASE is set.
+func promptPassphrase(create bool) (string, error) {
+	if p := os.Getenv(secret.PassphraseEnv); p != "" {
+		return p, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("encrypted secrets are locked — set %s", secret.PassphraseEnv)
+	}
+	fmt.Fprint(os.Stderr, "DAI passphrase: ")
+	p, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if len(p) == 0 {
+		return "", errors.New("empty passphrase")
+	}
+	if create {
+		fmt.Fprint(os.Stderr, "Repeat passphrase: ")
+		again, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if string(again) != string(p) {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	return string(p), nil
 }
 
 var configCmd = &cobra.Command{
@@ -88,23 +128,22 @@ var configSetKeyCmd = &cobra.Command{
 	Short: "Set OpenAI API key",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		fmt.Print("Enter your OpenAI API key (input hidden): ")
-		secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+		key, err := term.ReadPassword(int(os.Stdin.Fd()))
 		fmt.Println()
 		if err != nil {
 			return err
 		}
-		if len(secret) == 0 {
+		if len(key) == 0 {
 			return fmt.Errorf("empty key")
 		}
-		cfg, _ := config.Load()
+		cfg, _ := config.Read()
 		if cfg == nil {
 			cfg = &config.Config{}
 		}
-		cfg.OpenAIKey = string(secret)
 		if cfg.Model == "" {
 			cfg.Model = "gpt-4o-mini"
 		}
-		if err := config.Save(cfg); err != nil {
+		if err := cfg.SetOpenAIKey(cmd.Context(), string(key)); err != nil {
 			return err
 		}
 		fmt.Println("OpenAI key saved.")
@@ -116,7 +155,7 @@ var configSetModelCmd = &cobra.Command{
 	Use:   "set-model",
 	Short: "Interactively choose default model",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cfg, err := config.Load()
+		cfg, err := config.Read()
 		if err != nil {
 			return fmt.Errorf("run 'dai config set-key' or 'dai config wizard' first: %w", err)
 		}
@@ -161,19 +200,62 @@ var configShowCmd = &cobra.Command{
 	Use:   "show",
 	Short: "Show current global config (key masked)",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cfg, err := config.Load()
+		cfg, err := config.Read()
 		if err != nil {
 			return fmt.Errorf("no config — run 'dai config set-key'")
 		}
 		masked := "not set"
-		if cfg.OpenAIKey != "" {
-			if len(cfg.OpenAIKey) > 8 {
-				masked = cfg.OpenAIKey[:4] + "..." + cfg.OpenAIKey[len(cfg.OpenAIKey)-4:]
-			} else {
+		if cfg.OpenAIKeyRef != "" {
+			key, err := secret.Resolve(cmd.Context(), cfg.OpenAIKeyRef)
+			switch {
+			case err != nil:
+				masked = "unavailable (" + err.Error() + ")"
+			case len(key) > 8:
+				masked = key[:4] + "..." + key[len(key)-4:]
+			default:
 				masked = "****"
 			}
+			masked += " [" + secret.Backend(cfg.OpenAIKeyRef) + "]"
+		}
+		store := cfg.SecretStore
+		if store == "" {
+			store = secret.Plaintext
+		}
+		fmt.Printf("Model: %s\nOpenAI Key: %s\nSecret store: %s\n", cfg.Model, masked, store)
+		return nil
+	},
+}
+
+var configSetStoreCmd = &cobra.Command{
+	Use:   "set-store <" + strings.Join(secret.Backends(), "|") + ">",
+	Short: "Choose where secrets are stored and move the existing ones there",
+	Long: `Choose where the OpenAI key and the credentials stored by 'dai auth' are kept:
+
+  plaintext       in ~/.dai/config.yaml and ~/.dai/credentials.yaml (default)
+  encrypted       in ~/.dai/secrets.enc, encrypted with a passphrase
+                  (scrypt + AES-256-GCM; DAI_PASSPHRASE skips the prompt)
+  secret-service  in the desktop keyring (GNOME Keyring, KWallet) via secret-tool
+
+The config files then only hold references such as "encrypted:openai_key".
+A reference may also be "exec:<command>", e.g. "exec:op read op://Private/OpenAI/key",
+written by hand; those secrets are left where they are.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		backend := strings.TrimSpace(args[0])
+		if !secret.Valid(backend) {
+			return fmt.Errorf("unknown secret store %q (use %s)", backend, strings.Join(secret.Backends(), ", "))
+		}
+		if backend == secret.SecretService && !secret.SecretServiceAvailable() {
+			return errors.New("Secret Service not available (needs a D-Bus session and secret-tool from libsecret)")
+		}
+		moved, err := config.MoveSecrets(cmd.Context(), backend)
+		for _, name := range moved {
+			fmt.Printf("✓ %s → %s\n", name, backend)
+		}
+		if err != nil {
+			return err
 		}
-		fmt.Printf("Model: %s\nOpenAI Key: %s\n", cfg.Model, masked)
+		fmt.Println("Secret store set to", backend+".")
 		return nil
 	},
 }
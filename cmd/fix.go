@@ -0,0 +1,317 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gorankrgovic/dai/internal/gitutil"
+	"github.com/gorankrgovic/dai/internal/project"
+	"github.com/gorankrgovic/dai/internal/triage"
+)
+
+var (
+	flagFixModel   string
+	flagFixMaxKB   int
+	flagFixLogPath string
+	flagFixFormat  string // md|json
+	flagFixFresh   bool
+	flagFixNoVal   bool
+)
+
+func init() {
+	rootCmd.AddCommand(fixCmd)
+
+	fixCmd.Flags().StringVar(&flagFixModel, "model", "", "Override OpenAI model from config (optional)")
+	fixCmd.Flags().IntVar(&flagFixMaxKB, "max-file-kb", 200, "Max size of the file to fix (KB)")
+	fixCmd.Flags().StringVar(&flagFixLogPath, "log", ".dai/local.log", "Path to local log file (relative to project root)")
+	fixCmd.Flags().StringVar(&flagFixFormat, "format", "md", "Format of a fresh analysis in the log: md | json")
+	fixCmd.Flags().BoolVar(&flagFixFresh, "fresh", false, "Analyze the file again instead of using the last logged finding")
+	fixCmd.Flags().BoolVar(&flagFixNoVal, "no-validate", false, "Skip the validation command from .dai/project.yaml")
+}
+
+var fixCmd = &cobra.Command{
+	Use:   "fix <file>",
+	Short: "Fix a local finding, reviewing the patch hunk by hunk",
+	Long: `Take the most recent finding for a file from .dai/local.log (or run
+triage-local on it when there is none), ask the model for the smallest patch
+that fixes it, and walk through the patch hunk by hunk, like 'git add -p':
+
+  y  apply this hunk           n  skip this hunk
+  e  edit this hunk            a  apply this and all remaining hunks
+  q  quit; skip this and all remaining hunks
+
+Accepted hunks are written to the file in your working tree; nothing is
+committed or published. The patch must first pass validate.command from
+.dai/project.yaml in a throwaway worktree of your working tree (untracked
+files included, ignored ones not); without one, pass --no-validate.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := ensureProjectRoot()
+		if err != nil {
+			return err
+		}
+		p := args[0]
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(root, p)
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			return fmt.Errorf("cannot stat file: %w", err)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("path is a directory, expected a file: %s", p)
+		}
+		rel := relOrSame(root, p)
+		if filepath.IsAbs(rel) {
+			return fmt.Errorf("%s is outside the project", p)
+		}
+
+		var validate *project.Validate
+		if !flagFixNoVal {
+			prj, err := project.Load(root)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			if prj != nil {
+				validate = prj.Validate
+			}
+			if validate == nil {
+				return errors.New("no validate.command in .dai/project.yaml; configure one, or pass --no-validate to review an untested fix")
+			}
+		}
+
+		cfg, _, err := openAIConfig(flagFixModel)
+		if err != nil {
+			return err
+		}
+		maxBytes := int64(flagFixMaxKB) * 1024
+		logPath := localLogPath(root, flagFixLogPath)
+
+		var finding triage.Finding
+		logged, ok, err := triage.LastLocalFinding(logPath, rel)
+		if err != nil {
+			return err
+		}
+		if ok && !flagFixFresh {
+			finding = logged.Finding
+			fmt.Printf("Finding from %s (%s):\n", relOrSame(root, logPath), logged.Time.Local().Format("2006-01-02 15:04"))
+		} else {
+			fmt.Printf("Analyzing %s…\n", rel)
+			f, truncated, err := triage.AnalyzeLocal(cmd.Context(), cfg.OpenAIKey, cfg.Model, p, maxBytes)
+			if err != nil {
+				return err
+			}
+			if err := logLocalFinding(logPath, flagFixFormat, rel, cfg.Model, truncated, f); err != nil {
+				return err
+			}
+			if f.Type != "bug" && f.Type != "enhancement" {
+				fmt.Println("Nothing to fix.")
+				return nil
+			}
+			finding = f
+		}
+		finding.File = rel
+		fmt.Printf("  [%s", strings.ToUpper(finding.Type))
+		if finding.Severity != "" {
+			fmt.Printf("/%s", strings.ToUpper(finding.Severity))
+		}
+		fmt.Printf("] %s\n", safeLine(finding.Title))
+
+		opts := triage.Options{Root: root, OpenAIKey: cfg.OpenAIKey, Model: cfg.Model, MaxFileBytes: maxBytes, Validate: validate}
+		fix, err := triage.FixLocal(cmd.Context(), opts, finding)
+		if errors.Is(err, triage.ErrValidationFailed) {
+			fmt.Println(strings.TrimRight(fix.Validation.Output, "\n"))
+			fmt.Println("The fix was discarded.")
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if fix.Summary != "" {
+			fmt.Println("Fix:", safeLine(fix.Summary))
+		}
+		if fix.Validation != nil {
+			fmt.Println("✓ Validation:", fix.Validation.Summary())
+		}
+
+		hunks, err := gitutil.DiffContents(cmd.Context(), fix.Old, fix.New, 3)
+		if err != nil {
+			return err
+		}
+		accepted, err := reviewHunks(root, rel, hunks, bufio.NewReader(os.Stdin))
+		if err != nil {
+			return err
+		}
+		if len(accepted) == 0 {
+			fmt.Println("No hunks applied.")
+			return nil
+		}
+		out, err := gitutil.ApplyHunks(fix.Old, accepted)
+		if err != nil {
+			return err
+		}
+		cur, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if string(cur) != fix.Old {
+			return fmt.Errorf("%s changed while the fix was reviewed; nothing written", rel)
+		}
+		if err := os.WriteFile(p, []byte(out), info.Mode().Perm()); err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d of %d hunk(s) to %s.\n", len(accepted), len(hunks), rel)
+		return nil
+	},
+}
+
+// reviewHunks asks about each hunk and returns the accepted ones, edited
+// where the user chose to.
+func reviewHunks(root, file string, hunks []gitutil.Hunk, in *bufio.Reader) ([]gitutil.Hunk, error) {
+	var accepted []gitutil.Hunk
+	all := false
+	for i := 0; i < len(hunks); i++ {
+		h := hunks[i]
+		fmt.Printf("\n--- a/%s\n+++ b/%s\n", file, file)
+		printHunk(h)
+		if all {
+			accepted = append(accepted, h)
+			continue
+		}
+		fmt.Printf("(%d/%d) Apply this hunk [y,n,e,a,q,?]? ", i+1, len(hunks))
+		line, err := in.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if errors.Is(err, io.EOF) && line == "" {
+			fmt.Println()
+			return accepted, nil
+		}
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "y":
+			accepted = append(accepted, h)
+		case "n":
+		case "a":
+			accepted = append(accepted, h)
+			all = true
+		case "q":
+			return accepted, nil
+		case "e":
+			edited, err := editHunk(root, h)
+			if err != nil {
+				fmt.Println("Edit discarded:", err)
+				i-- // ask again
+				continue
+			}
+			if edited != nil {
+				accepted = append(accepted, *edited)
+			}
+		default:
+			fmt.Println(`y - apply this hunk
+n - do not apply this hunk
+e - manually edit this hunk
+a - apply this hunk and all remaining hunks
+q - quit; do not apply this hunk or any of the remaining ones`)
+			i--
+		}
+	}
+	return accepted, nil
+}
+
+func printHunk(h gitutil.Hunk) {
+	fmt.Printf("@@ -%d,%d +%d,%d @@\n", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+	for _, l := range h.Lines {
+		fmt.Println(l)
+	}
+}
+
+// editHunk opens h in git's editor and returns the edited hunk, or nil
+// when it no longer changes anything. The old side must stay as it was.
+func editHunk(root string, h gitutil.Hunk) (*gitutil.Hunk, error) {
+	editor, err := gitutil.Editor(root)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp("", "dai-hunk-*.diff")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	fmt.Fprintf(f, "# Manual hunk edit mode. Edit the hunk below and save.\n")
+	fmt.Fprintf(f, "# To drop a '+' line, delete it. To keep a '-' line, make it a ' ' line.\n")
+	fmt.Fprintf(f, "# Lines starting with # are removed; an empty hunk is not applied.\n")
+	fmt.Fprintf(f, "@@ -%d,%d +%d,%d @@\n", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+	for _, l := range h.Lines {
+		fmt.Fprintln(f, l)
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	// like git: the editor is a shell snippet, the file its argument
+	c := exec.Command("sh", "-c", editor+` "$@"`, editor, f.Name())
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		return nil, fmt.Errorf("editor: %w", err)
+	}
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		return nil, err
+	}
+	return parseEditedHunk(h, string(b))
+}
+
+func parseEditedHunk(orig gitutil.Hunk, text string) (*gitutil.Hunk, error) {
+	out := gitutil.Hunk{OldStart: orig.OldStart, NewStart: orig.NewStart}
+	var changes int
+	for _, l := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(l, "#"), strings.HasPrefix(l, "@@"):
+			continue
+		case l == "":
+			l = " " // an emptied context line
+		}
+		switch l[0] {
+		case ' ':
+			out.OldLines++
+			out.NewLines++
+		case '-':
+			out.OldLines++
+			changes++
+		case '+':
+			out.NewLines++
+			changes++
+		case '\\':
+		default:
+			return nil, fmt.Errorf("line %q does not start with ' ', '+' or '-'", l)
+		}
+		out.Lines = append(out.Lines, l)
+	}
+	if changes == 0 {
+		return nil, nil
+	}
+	if !slices.Equal(oldSide(orig), oldSide(out)) {
+		return nil, errors.New("the context and '-' lines no longer match the file")
+	}
+	return &out, nil
+}
+
+// oldSide returns the lines h expects in the file.
+func oldSide(h gitutil.Hunk) []string {
+	var out []string
+	for _, l := range h.Lines {
+		if l != "" && (l[0] == ' ' || l[0] == '-') {
+			out = append(out, l[1:])
+		}
+	}
+	return out
+}
@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	survey "github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/gorankrgovic/dai/internal/gitutil"
+	"github.com/gorankrgovic/dai/internal/hook"
+	"github.com/gorankrgovic/dai/internal/project"
+	"github.com/gorankrgovic/dai/internal/triage"
+)
+
+var (
+	flagHookNames string
+	flagHookForce bool
+)
+
+func init() {
+	rootCmd.AddCommand(hookCmd)
+	hookCmd.AddCommand(hookInstallCmd)
+	hookCmd.AddCommand(hookUninstallCmd)
+	hookCmd.AddCommand(hookStatusCmd)
+	hookCmd.AddCommand(hookRunCmd)
+
+	hookInstallCmd.Flags().StringVar(&flagHookNames, "hook", "pre-commit,pre-push", "Comma-separated hooks to install")
+	hookInstallCmd.Flags().BoolVar(&flagHookForce, "force", false, "Replace existing non-dai hooks (the original is kept as <hook>.pre-dai)")
+	hookUninstallCmd.Flags().StringVar(&flagHookNames, "hook", "pre-commit,pre-push", "Comma-separated hooks to remove")
+}
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Manage git hooks that triage staged changes and outgoing commits",
+	Long: `Manage git hooks that run DAI before commits and pushes.
+
+pre-commit triages the staged changes, pre-push triages the commits being pushed.
+Both block when a finding meets the severity threshold from .dai/project.yaml:
+
+  hooks:
+    fail_on: high   # low | medium | high
+
+Set ` + hook.SkipEnv + `=1 (or use git's --no-verify) to bypass the hooks once.`,
+}
+
+var hookInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install DAI git hooks (honours core.hooksPath)",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, names, err := hookTargets(flagHookNames)
+		if err != nil {
+			return err
+		}
+		return installHooks(dir, names, flagHookForce)
+	},
+}
+
+var hookUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove DAI git hooks (restores hooks they replaced)",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, names, err := hookTargets(flagHookNames)
+		if err != nil {
+			return err
+		}
+		for _, n := range names {
+			removed, err := hook.Uninstall(dir, n)
+			if err != nil {
+				return err
+			}
+			if removed {
+				fmt.Printf("✓ Removed %s\n", n)
+			} else {
+				fmt.Printf("- %s: no DAI hook installed\n", n)
+			}
+		}
+		return nil
+	},
+}
+
+var hookStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which DAI git hooks are installed",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, names, err := hookTargets(strings.Join(hook.Names, ","))
+		if err != nil {
+			return err
+		}
+		fmt.Println("Hooks dir:", dir)
+		for _, n := range names {
+			st, err := hook.Check(dir, n)
+			if err != nil {
+				return err
+			}
+			switch {
+			case st.Installed && st.Backup:
+				fmt.Printf("✓ %s: installed (original kept as %s.pre-dai)\n", n, n)
+			case st.Installed:
+				fmt.Printf("✓ %s: installed\n", n)
+			case st.Foreign:
+				fmt.Printf("! %s: another hook is installed\n", n)
+			default:
+				fmt.Printf("✗ %s: not installed\n", n)
+			}
+		}
+		if os.Getenv(hook.SkipEnv) != "" {
+			fmt.Printf("Note: %s is set, hooks are bypassed in this shell.\n", hook.SkipEnv)
+		}
+		return nil
+	},
+}
+
+// hookRunCmd is what the installed scripts call.
+var hookRunCmd = &cobra.Command{
+	Use:    "run <hook>",
+	Short:  "Run a DAI hook (called by the installed git hooks)",
+	Hidden: true,
+	// git passes hook arguments after the name (pre-push: remote name and
+	// URL); they are not needed.
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if os.Getenv(hook.SkipEnv) != "" {
+			return nil
+		}
+		if !hook.IsValid(args[0]) {
+			return fmt.Errorf("unknown hook %q", args[0])
+		}
+		return runHook(cmd.Context(), args[0], os.Stdin)
+	},
+}
+
+func hookTargets(csv string) (string, []string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", nil, err
+	}
+	if !gitutil.IsRepo(wd) {
+		return "", nil, fmt.Errorf("not a git repository: %s", wd)
+	}
+	dir, err := gitutil.HooksDir(wd)
+	if err != nil {
+		return "", nil, err
+	}
+	var names []string
+	for _, n := range strings.Split(csv, ",") {
+		n = strings.TrimSpace(n)
+		if n == "" {
+			continue
+		}
+		if !hook.IsValid(n) {
+			return "", nil, fmt.Errorf("unknown hook %q (use %s)", n, strings.Join(hook.Names, ", "))
+		}
+		names = append(names, n)
+	}
+	return dir, names, nil
+}
+
+func installHooks(dir string, names []string, force bool) error {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "dai"
+	}
+	for _, n := range names {
+		path, err := hook.Install(dir, n, exe, force)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ Installed %s\n", path)
+	}
+	return nil
+}
+
+// promptInstallHooks is called from the `dai init` flow.
+func promptInstallHooks(repoRoot string) error {
+	var install bool
+	if err := survey.AskOne(&survey.Confirm{
+		Message: "Install DAI git hooks (pre-commit, pre-push) to triage changes before they leave your machine?",
+		Default: false,
+	}, &install); err != nil {
+		return err
+	}
+	if !install {
+		return nil
+	}
+	dir, err := gitutil.HooksDir(repoRoot)
+	if err != nil {
+		return err
+	}
+	if err := installHooks(dir, hook.Names, false); err != nil {
+		// an existing hook is not a reason to fail init
+		fmt.Println("!", err)
+	}
+	return nil
+}
+
+func runHook(ctx context.Context, name string, stdin io.Reader) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	root, err := gitutil.RepoRoot(wd)
+	if err != nil {
+		return err
+	}
+
+	// an unconfigured dai should not stop anyone from committing
+	cfg, _, err := openAIConfig("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dai %s: skipped (%v)\n", name, err)
+		return nil
+	}
+
+	failOn := "high"
+	exts := splitCSV(defaultTriageExts)
+	if prj, err := project.Load(root); err == nil && prj.Hooks != nil {
+		if prj.Hooks.FailOn != "" {
+			if failOn, err = triage.ParseSeverity(prj.Hooks.FailOn); err != nil {
+				return fmt.Errorf("hooks.fail_on in %s: %w", project.Path(root), err)
+			}
+		}
+		if len(prj.Hooks.Ext) > 0 {
+			exts = splitCSV(strings.Join(prj.Hooks.Ext, ","))
+		}
+	}
+
+	base := triage.Options{
+		Root:        root,
+		OpenAIKey:   cfg.OpenAIKey,
+		Model:       cfg.Model,
+		IncludeExts: exts,
+		IgnoreFile:  filepath.Join(root, ".daiignore"),
+		DryRun:      true,
+		DiffContext: 3,
+	}
+
+	var findings []triage.Finding
+	switch name {
+	case hook.PreCommit:
+		opt := base
+		opt.Staged = true
+		a, err := triage.Analyze(ctx, opt)
+		if err != nil {
+			return err
+		}
+		findings = a.Findings
+	case hook.PrePush:
+		// stdin: <local ref> <local sha> <remote ref> <remote sha>
+		sc := bufio.NewScanner(stdin)
+		for sc.Scan() {
+			f := strings.Fields(sc.Text())
+			if len(f) != 4 || f[1] == gitutil.ZeroSHA {
+				continue // malformed or branch deletion
+			}
+			opt := base
+			opt.Commit, opt.Base = f[1], f[3]
+			// the remote tip may be missing locally, e.g. after a
+			// force-push over commits never fetched
+			if opt.Base == gitutil.ZeroSHA || !gitutil.HasCommit(root, opt.Base) {
+				if opt.Base, err = gitutil.OutgoingBase(root, f[1]); err != nil {
+					return err
+				}
+				if opt.Base == f[1] {
+					continue // nothing new
+				}
+				if opt.Base == "" {
+					if opt.Base, err = gitutil.EmptyTree(root); err != nil {
+						return err
+					}
+				}
+			}
+			a, err := triage.Analyze(ctx, opt)
+			if err != nil {
+				return err
+			}
+			findings = append(findings, a.Findings...)
+		}
+		if err := sc.Err(); err != nil {
+			return err
+		}
+	}
+
+	blocking := triage.AtLeast(findings, failOn)
+	if len(blocking) == 0 {
+		fmt.Fprintf(os.Stderr, "dai %s: ok (no findings at %s severity or above)\n", name, failOn)
+		return nil
+	}
+	for _, f := range blocking {
+		fmt.Fprintf(os.Stderr, "✗ [%s/%s] %s — %s", strings.ToUpper(f.Severity), f.Type, safeLine(f.Title), f.File)
+		if f.StartLine > 0 {
+			fmt.Fprintf(os.Stderr, ":%d", f.StartLine)
+		}
+		fmt.Fprintln(os.Stderr)
+		if f.Details != "" {
+			fmt.Fprintf(os.Stderr, "    %s\n", safeLine(f.Details))
+		}
+	}
+	return fmt.Errorf("dai %s: blocked by %d finding(s) at %s severity or above (bypass once with %s=1 or --no-verify)", name, len(blocking), failOn, hook.SkipEnv)
+}
+
+func safeLine(s string) string {
+	return strings.TrimSpace(strings.ReplaceAll(s, "\n", " "))
+}
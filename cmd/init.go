@@ -12,6 +12,7 @@ import (
 
 	"github.com/gorankrgovic/dai/internal/gitutil"
 	"github.com/gorankrgovic/dai/internal/project"
+	"github.com/gorankrgovic/dai/internal/tracker"
 )
 
 var (
@@ -64,12 +65,25 @@ var initCmd = &cobra.Command{
 			fmt.Println("Detected origin:", remote)
 		}
 
-		owner, repo := gitutil.ParseOwnerRepo(remote)
-		if owner == "" || repo == "" {
-			return fmt.Errorf("could not parse owner/repo from origin URL: %s\nTip: use a GitHub URL (ssh or https), e.g. git@github.com:ORG/REPO.git", remote)
+		rem, err := gitutil.ParseRemote(remote)
+		if err != nil {
+			return fmt.Errorf("could not parse origin URL: %w\nTip: use an ssh or https remote, e.g. git@github.com:ORG/REPO.git", err)
 		}
 
 		projectPath := filepath.Join(baseDir, ".dai", "project.yaml")
+
+		// a provider set in an existing project.yaml wins over the guess, since
+		// self-hosted servers rarely have telling host names, but only while
+		// origin stays on the same host (or the host gives no hint)
+		provider := gitutil.GuessProvider(rem.Host)
+		prev, _ := project.Load(baseDir)
+		if prev != nil && prev.Provider != "" && (provider == "" || strings.EqualFold(prev.APIHost(), rem.Host)) {
+			provider = prev.Provider
+		}
+		if provider == "" {
+			provider = "github"
+		}
+
 		if _, err := os.Stat(projectPath); err == nil && !initFlagForce {
 			confirm := false
 			if err := survey.AskOne(&survey.Confirm{
@@ -85,7 +99,16 @@ var initCmd = &cobra.Command{
 		}
 
 		// 3) project config inside ROOT
-		p := &project.Project{Provider: "github", Owner: owner, Repo: repo}
+		p := &project.Project{Provider: provider, Host: rem.Host, Owner: rem.Namespace, Repo: rem.Repo}
+		if prev != nil {
+			p.Hooks = prev.Hooks
+			p.Jira = prev.Jira
+			p.Notify = prev.Notify
+			p.Validate = prev.Validate
+			if prev.Host == p.Host {
+				p.APIURL = prev.APIURL
+			}
+		}
 		if err := project.Save(baseDir, p); err != nil {
 			return err
 		}
@@ -110,8 +133,16 @@ var initCmd = &cobra.Command{
 			return err
 		}
 
+		// 5) optional git hooks
+		if err := promptInstallHooks(baseDir); err != nil {
+			return err
+		}
+
 		fmt.Printf("DAI project initialized at %s\n", projectPath)
-		fmt.Printf("Detected repo: %s/%s\n", owner, repo)
+		fmt.Printf("Detected repo: %s/%s (%s, %s)\n", p.Owner, p.Repo, p.Host, p.Provider)
+		if !tracker.Supported(p.Provider) {
+			fmt.Printf("Note: publishing to %s is not supported yet; set 'provider' in project.yaml if the guess is wrong.\n", p.Provider)
+		}
 		fmt.Println("If you change origin, run 'dai init' again to update project config.")
 		return nil
 	},
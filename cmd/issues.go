@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gorankrgovic/dai/internal/triage"
+)
+
+var (
+	flagReconcileDryRun bool
+	flagReconcileModel  string
+	flagReconcileMaxKB  int
+)
+
+func init() {
+	rootCmd.AddCommand(issuesCmd)
+	issuesCmd.AddCommand(issuesReconcileCmd)
+
+	issuesReconcileCmd.Flags().BoolVar(&flagReconcileDryRun, "dry-run", false, "Show which findings are fixed without commenting or closing")
+	issuesReconcileCmd.Flags().StringVar(&flagReconcileModel, "model", "", "Override OpenAI model from config (optional)")
+	issuesReconcileCmd.Flags().IntVar(&flagReconcileMaxKB, "max-file-kb", 512, "Max file size read for a recheck (KB)")
+}
+
+var issuesCmd = &cobra.Command{
+	Use:   "issues",
+	Short: "Manage the issues created by DAI",
+}
+
+var issuesReconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Close DAI issues whose findings are fixed at HEAD",
+	Long: `Re-check the findings of every open DAI issue against the HEAD version of
+their files. Fixed findings are reported in a comment; issues whose findings
+are all fixed are closed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		wd, err := ensureProjectRoot()
+		if err != nil {
+			return err
+		}
+		prj, err := loadProject(wd)
+		if err != nil {
+			return err
+		}
+		opts, err := triageOptions(cmd.Context(), wd, prj, flagReconcileModel)
+		if err != nil {
+			return err
+		}
+		opts.DryRun = flagReconcileDryRun
+		opts.MaxFileBytes = int64(flagReconcileMaxKB) * 1024
+
+		results, err := triage.Reconcile(cmd.Context(), opts)
+		if err != nil {
+			return err
+		}
+		if opts.DryRun {
+			fmt.Println("— DRY RUN —")
+		}
+		closed := 0
+		for _, r := range results {
+			fmt.Printf("#%d %s\n", r.Number, safeLine(r.Title))
+			for _, c := range r.Checks {
+				mark := "·"
+				if c.Fixed {
+					mark = "✓"
+				}
+				fmt.Printf("  %s %s (%s)", mark, safeLine(c.Finding.Title), c.Finding.File)
+				if c.Reason != "" {
+					fmt.Printf(": %s", safeLine(c.Reason))
+				}
+				fmt.Println()
+			}
+			if r.Closed {
+				closed++
+			}
+		}
+		verb := "Closed"
+		if opts.DryRun {
+			verb = "Would close"
+		}
+		fmt.Printf("%s %d of %d open DAI issue(s).\n", verb, closed, len(results))
+		return nil
+	},
+}
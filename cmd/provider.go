@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gorankrgovic/dai/internal/config"
+	"github.com/gorankrgovic/dai/internal/credential"
+	"github.com/gorankrgovic/dai/internal/gh"
+	"github.com/gorankrgovic/dai/internal/project"
+	"github.com/gorankrgovic/dai/internal/secret"
+	"github.com/gorankrgovic/dai/internal/tracker"
+	"github.com/gorankrgovic/dai/internal/triage"
+)
+
+// gitlabCI reports whether dai runs in a GitLab CI job.
+func gitlabCI() bool {
+	return os.Getenv("GITLAB_CI") == "true"
+}
+
+// gitlabCIProject builds the project of the running GitLab CI job.
+func gitlabCIProject() (*project.Project, error) {
+	ns, name := os.Getenv("CI_PROJECT_NAMESPACE"), os.Getenv("CI_PROJECT_NAME")
+	if ns == "" || name == "" {
+		return nil, fmt.Errorf("CI_PROJECT_NAMESPACE/CI_PROJECT_NAME not set")
+	}
+	return &project.Project{
+		Provider: tracker.GitLab,
+		Host:     os.Getenv("CI_SERVER_HOST"),
+		Owner:    ns,
+		Repo:     name,
+		APIURL:   os.Getenv("CI_API_V4_URL"),
+	}, nil
+}
+
+// apiBase returns the REST API root for the project's host, honouring an
+// explicit api_url in project.yaml and, in GitLab CI, CI_API_V4_URL.
+func apiBase(prj *project.Project) string {
+	if prj.APIURL != "" {
+		return prj.APIURL
+	}
+	if prj.Provider == tracker.GitLab && gitlabCI() && os.Getenv("CI_SERVER_HOST") == prj.Host {
+		if u := os.Getenv("CI_API_V4_URL"); u != "" {
+			return u
+		}
+	}
+	return tracker.APIBase(prj.Provider, prj.APIHost())
+}
+
+// loadProject loads project.yaml (or, in GitLab CI without one, the job's
+// project) and checks dai can publish to its provider.
+func loadProject(wd string) (*project.Project, error) {
+	prj, err := project.Load(wd)
+	if os.IsNotExist(err) && gitlabCI() {
+		prj, err = gitlabCIProject()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("project config not found — run 'dai init' first: %w", err)
+	}
+	if prj.Provider == tracker.Forgejo {
+		// same API and token as Gitea
+		prj.Provider = tracker.Gitea
+	}
+	if prj.Owner == "" || prj.Repo == "" {
+		return nil, fmt.Errorf("project config missing owner/repo")
+	}
+	if !tracker.Supported(prj.Provider) {
+		return nil, fmt.Errorf("provider %q is not supported yet", prj.Provider)
+	}
+	return prj, nil
+}
+
+// tokenEnv lists the variables checked, in order, before the stored token
+// (e.g. masked CI variables).
+var tokenEnv = map[string][]string{
+	tracker.GitHub:   {"DAI_GITHUB_TOKEN", "GITHUB_TOKEN"},
+	tracker.GitLab:   {"DAI_GITLAB_TOKEN", "GITLAB_TOKEN"},
+	tracker.Gitea:    {"DAI_GITEA_TOKEN", "GITEA_TOKEN"},
+	providerJira:     {"DAI_JIRA_TOKEN"},
+	serviceOpenAI:    {"OPENAI_API_KEY"},
+	serviceAnthropic: {"ANTHROPIC_API_KEY"},
+}
+
+// resolveToken finds the API token for provider on host: tokenEnv first,
+// then for GitHub the gh CLI and git credential helpers, and finally the
+// credential stored by 'dai auth login'.
+func resolveToken(ctx context.Context, provider, host string) (credential.Credential, error) {
+	if provider == "" {
+		provider = tracker.GitHub
+	}
+	if c, ok := credential.Env(tokenEnv[provider]...); ok {
+		return c, nil
+	}
+	if provider == tracker.GitHub {
+		if host == "" {
+			host = "github.com"
+		}
+		if c, ok := credential.GhCLI(ctx, host); ok {
+			return c, nil
+		}
+		if c, ok := credential.GitCredential(ctx, host); ok {
+			return c, nil
+		}
+	}
+	token, c, err := config.LoadCredential(ctx, provider, host)
+	if err == nil && token == "" {
+		err = fmt.Errorf("stored token is empty")
+	}
+	if err != nil {
+		login := "dai auth login " + provider
+		if !config.DefaultApplies(provider, host) {
+			login += " --host " + host
+		}
+		return credential.Credential{}, fmt.Errorf("%s token not found — run '%s' first: %w", authServices[provider], login, err)
+	}
+	src := c.Where()
+	if c.Host != "" {
+		src += " [" + c.Host + "]"
+	}
+	return credential.Credential{Secret: token, Source: src}, nil
+}
+
+// providerToken returns the token resolveToken finds.
+func providerToken(ctx context.Context, provider, host string) (string, error) {
+	c, err := resolveToken(ctx, provider, host)
+	if err != nil {
+		return "", err
+	}
+	return c.Secret, nil
+}
+
+// openAIConfig loads the global config with the OpenAI key from
+// OPENAI_API_KEY or 'dai auth login openai', if set, or the config file.
+// A non-empty model overrides the configured one.
+func openAIConfig(model string) (*config.Config, credential.Credential, error) {
+	stored, storeErr := resolveToken(context.Background(), serviceOpenAI, "")
+	load := config.Load
+	if storeErr == nil {
+		// the key in config.yaml is not needed, so do not unlock its store
+		load = config.Read
+	}
+	cfg, err := load()
+	if err != nil {
+		if storeErr != nil || !errors.Is(err, os.ErrNotExist) {
+			return nil, credential.Credential{}, fmt.Errorf("global config not found — run 'dai config' first: %w", err)
+		}
+		cfg = &config.Config{Model: "gpt-4o-mini"}
+	}
+	src := credential.Credential{Secret: cfg.OpenAIKey, Source: "~/.dai/config.yaml"}
+	if cfg.OpenAIKeyRef != "" && secret.Backend(cfg.OpenAIKeyRef) != secret.Plaintext {
+		src.Source = cfg.OpenAIKeyRef
+	}
+	if storeErr == nil {
+		src = stored
+		cfg.OpenAIKey = stored.Secret
+	}
+	if model != "" {
+		cfg.Model = model
+	}
+	if strings.TrimSpace(cfg.OpenAIKey) == "" {
+		return nil, credential.Credential{}, fmt.Errorf("OpenAI key missing in global config — run 'dai config' or set OPENAI_API_KEY")
+	}
+	return cfg, src, nil
+}
+
+// githubApp returns installation tokens for prj when a GitHub App is set
+// up, through DAI_GITHUB_APP_ID and DAI_GITHUB_APP_PRIVATE_KEY (PEM or a
+// path to one) or 'dai auth --app-id'; nil when none is.
+func githubApp(prj *project.Project) (gh.TokenSource, error) {
+	app := config.GitHubApp{AppID: strings.TrimSpace(os.Getenv("DAI_GITHUB_APP_ID"))}
+	var pem []byte
+	if app.AppID != "" {
+		key := strings.TrimSpace(os.Getenv("DAI_GITHUB_APP_PRIVATE_KEY"))
+		if key == "" {
+			return nil, fmt.Errorf("DAI_GITHUB_APP_ID is set but DAI_GITHUB_APP_PRIVATE_KEY is not")
+		}
+		if strings.HasPrefix(key, "-----BEGIN") {
+			pem = []byte(key)
+		} else {
+			app.PrivateKeyPath = key
+		}
+		if id := os.Getenv("DAI_GITHUB_APP_INSTALLATION_ID"); id != "" {
+			n, err := strconv.ParseInt(id, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("DAI_GITHUB_APP_INSTALLATION_ID: %w", err)
+			}
+			app.InstallationID = n
+		}
+	} else {
+		stored, err := config.LoadGitHubApp()
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("load GitHub App config: %w", err)
+		}
+		app = *stored
+	}
+	if pem == nil {
+		b, err := os.ReadFile(app.PrivateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("read GitHub App private key: %w", err)
+		}
+		pem = b
+	}
+	key, err := gh.ParsePrivateKey(pem)
+	if err != nil {
+		return nil, err
+	}
+	src := gh.NewAppTokenSource(apiBase(prj), app.AppID, key, app.InstallationID, prj.Owner, prj.Repo)
+	src.Cache = &config.AppTokenCache{}
+	return src, nil
+}
+
+// triageOptions loads the provider token and the OpenAI config into the
+// options every tracker-backed triage command starts from. A non-empty
+// model overrides the configured one.
+func triageOptions(ctx context.Context, wd string, prj *project.Project, model string) (triage.Options, error) {
+	var (
+		token  string
+		tokens gh.TokenSource
+		err    error
+	)
+	if prj.Provider == "" || prj.Provider == tracker.GitHub {
+		if tokens, err = githubApp(prj); err != nil {
+			return triage.Options{}, err
+		}
+	}
+	if tokens == nil {
+		if token, err = providerToken(ctx, prj.Provider, prj.APIHost()); err != nil {
+			return triage.Options{}, err
+		}
+	}
+
+	cfg, _, err := openAIConfig(model)
+	if err != nil {
+		return triage.Options{}, err
+	}
+
+	return triage.Options{
+		Root:         wd,
+		Provider:     prj.Provider,
+		Owner:        prj.Owner,
+		Repo:         prj.Repo,
+		Token:        token,
+		GitHubTokens: tokens,
+		APIBase:      apiBase(prj),
+		OpenAIKey:    cfg.OpenAIKey,
+		Model:        cfg.Model,
+	}, nil
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gorankrgovic/dai/internal/triage"
+)
+
+var (
+	flagReviewExt     string
+	flagReviewDryRun  bool
+	flagReviewModel   string
+	flagReviewIgnore  string
+	flagReviewMention bool
+)
+
+func init() {
+	rootCmd.AddCommand(reviewCmd)
+
+	reviewCmd.Flags().StringVar(&flagReviewExt, "ext", defaultTriageExts, "Comma-separated file extensions to analyze")
+	reviewCmd.Flags().BoolVar(&flagReviewDryRun, "dry-run", false, "Print the would-be review without submitting it")
+	reviewCmd.Flags().StringVar(&flagReviewModel, "model", "", "Override OpenAI model from config (optional)")
+	reviewCmd.Flags().StringVar(&flagReviewIgnore, "ignore", ".daiignore", "Path to ignore file (gitignore syntax), relative to project root")
+	reviewCmd.Flags().BoolVar(&flagReviewMention, "mention-authors", false, "@-mention the author of the flagged lines in each comment")
+}
+
+var reviewCmd = &cobra.Command{
+	Use:   "review <pr-number>",
+	Short: "Triage a pull or merge request and post the findings as inline review comments",
+	Long: `Triage a pull request (GitHub) or merge request (GitLab) and submit one review.
+
+Findings on changed lines become inline comments (GitLab: diff discussions);
+everything else goes into the review summary. Running it again updates earlier
+DAI comments, resolves the ones whose finding is gone and marks older DAI
+summaries as superseded.
+
+In a GitLab merge request pipeline the number defaults to CI_MERGE_REQUEST_IID.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		arg := os.Getenv("CI_MERGE_REQUEST_IID")
+		if len(args) == 1 {
+			arg = args[0]
+		}
+		if arg == "" {
+			return fmt.Errorf("missing pull request number")
+		}
+		number, err := strconv.Atoi(strings.TrimLeft(arg, "#!"))
+		if err != nil || number <= 0 {
+			return fmt.Errorf("invalid pull request number %q", arg)
+		}
+		wd, err := ensureProjectRoot()
+		if err != nil {
+			return err
+		}
+		prj, err := loadProject(wd)
+		if err != nil {
+			return err
+		}
+		opts, err := triageOptions(cmd.Context(), wd, prj, flagReviewModel)
+		if err != nil {
+			return err
+		}
+		opts.IncludeExts = splitCSV(flagReviewExt)
+		opts.IgnoreFile = filepath.Join(wd, flagReviewIgnore)
+		opts.DryRun = flagReviewDryRun
+		// GitHub and GitLab only accept comments on lines of their own
+		// 3-line-context diff
+		opts.DiffContext = 3
+		if flagReviewMention {
+			opts.AssignAuthor = triage.AssignMention
+		}
+
+		result, err := triage.Review(cmd.Context(), opts, number)
+		if err != nil {
+			return err
+		}
+		if opts.DryRun {
+			fmt.Println("— DRY RUN —")
+			fmt.Println(result.Body)
+			for _, c := range result.Comments {
+				line := strconv.Itoa(c.Line)
+				if c.StartLine > 0 {
+					line = fmt.Sprintf("%d-%d", c.StartLine, c.Line)
+				}
+				fmt.Printf("\n--- %s:%s ---\n%s\n", c.Path, line, c.Body)
+			}
+			fmt.Printf("\nWould update %d and resolve %d earlier comment(s).\n", result.Updated, result.Resolved)
+			return nil
+		}
+		fmt.Printf("✓ Review submitted: %s (%d new, %d updated, %d resolved)\n", result.URL, len(result.Comments), result.Updated, result.Resolved)
+		return nil
+	},
+}
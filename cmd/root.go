@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gorankrgovic/dai/internal/buildinfo"
@@ -41,7 +44,9 @@ var rootCmd = &cobra.Command{
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVarP(&parrotMode, "parrot", "p", "", "Summon the DAI parrot (modes: party, insult, wise)")
+	// -p is handled by parseParrotMode; registering it here would clash
+	// with dai init -p (--path)
+	rootCmd.PersistentFlags().StringVar(&parrotMode, "parrot", "", "Summon the DAI parrot (modes: party, insult, wise); -p for short")
 }
 
 // Execute is the main entry point
@@ -52,20 +57,35 @@ func Execute() {
 		os.Exit(0)
 	}
 
-	if err := rootCmd.Execute(); err != nil {
+	// cancelled on Ctrl-C so running git processes and requests are stopped
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		fmt.Println(err)
+		stop()
 		os.Exit(1)
 	}
 }
 
 func parseParrotMode(args []string) string {
-	for _, a := range args {
-		if a == "--parrot" || a == "-p" {
+	sub := "" // first non-flag argument: the subcommand
+	for _, a := range args[min(1, len(args)):] {
+		if sub == "" && !strings.HasPrefix(a, "-") {
+			sub = a
+		}
+		if a == "--parrot" {
 			return "basic"
 		}
 		if strings.HasPrefix(a, "--parrot=") {
 			return strings.ToLower(strings.TrimPrefix(a, "--parrot="))
 		}
+		if sub == "init" {
+			continue // -p is init's --path
+		}
+		if a == "-p" {
+			return "basic"
+		}
 		if strings.HasPrefix(a, "-p=") {
 			return strings.ToLower(strings.TrimPrefix(a, "-p="))
 		}
@@ -4,11 +4,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
 
-	"github.com/gorankrgovic/dai/internal/config"
+	"github.com/gorankrgovic/dai/internal/gitutil"
 	"github.com/gorankrgovic/dai/internal/project"
 	"github.com/gorankrgovic/dai/internal/triage"
 )
@@ -21,56 +22,74 @@ var (
 	flagIgnorePath   string
 	flagAlwaysOpen   bool
 	flagDiffContext  int
+	flagMergeMode    string
+	flagAssignAuthor string
+	flagTriagePR     int
+	flagTriageRepo   string
+	flagNewIssue     bool
+	flagPublish      string
+	flagFailOn       string
+	flagNoNotify     bool
 )
 
+// defaultTriageExts is the extension list used when none is configured.
+const defaultTriageExts = ".js,.jsx,.ts,.tsx,.vue,.php,.py,.go"
+
 func init() {
 	rootCmd.AddCommand(triageCmd)
 
-	triageCmd.Flags().StringVar(&flagTriageExt, "ext", ".js,.jsx,.ts,.tsx,.vue,.php,.py,.go", "Comma-separated file extensions to analyze")
-	triageCmd.Flags().BoolVar(&flagTriageDryRun, "dry-run", false, "Print the would-be GitHub issue without creating it")
+	triageCmd.Flags().StringVar(&flagTriageExt, "ext", defaultTriageExts, "Comma-separated file extensions to analyze")
+	triageCmd.Flags().BoolVar(&flagTriageDryRun, "dry-run", false, "Print the would-be issue without creating it")
 	triageCmd.Flags().StringVar(&flagModel, "model", "", "Override OpenAI model from config (optional)")
 	triageCmd.Flags().IntVar(&flagMaxKB, "max-file-kb", 80, "Max file size per analyzed file (KB)")
 	triageCmd.Flags().StringVar(&flagIgnorePath, "ignore", ".daiignore", "Path to ignore file (gitignore syntax), relative to project root")
-	triageCmd.Flags().BoolVar(&flagAlwaysOpen, "always-open", false, "Always create a GitHub issue even when no findings")
+	triageCmd.Flags().BoolVar(&flagAlwaysOpen, "always-open", false, "Always create an issue even when no findings")
 	triageCmd.Flags().IntVar(&flagDiffContext, "diff-context", 3, "Number of context lines per diff hunk")
+	triageCmd.Flags().StringVar(&flagMergeMode, "merge-mode", "first-parent", "How to triage merge commits: first-parent | conflicts | evil")
+	triageCmd.Flags().StringVar(&flagAssignAuthor, "assign-author", "", "Attribute findings to their authors: assign (issue assignees) | mention (@-mention in body)")
+	triageCmd.Flags().BoolVar(&flagNewIssue, "new-issue", false, "Always open a new issue instead of updating an existing DAI issue")
+	triageCmd.Flags().StringVar(&flagPublish, "publish", triage.PublishIssue, "Comma-separated publish targets: issue, jira, checks, status, commit-comment")
+	triageCmd.Flags().StringVar(&flagFailOn, "fail-on", "high", "Severity that fails the check run or commit status: low | medium | high")
+	triageCmd.Flags().BoolVar(&flagNoNotify, "no-notify", false, "Do not send the notifications configured in project.yaml")
+	triageCmd.Flags().IntVar(&flagTriagePR, "pr", 0, "Triage a pull request through the GitHub API instead of a local commit")
+	triageCmd.Flags().StringVar(&flagTriageRepo, "repo", "", "Repository of --pr as owner/name or host/owner/name (no dai project needed)")
 }
 
 var triageCmd = &cobra.Command{
-	Use:   "triage [commit]",
-	Short: "Analyze a commit and open a single GitHub issue with findings",
+	Use:   "triage [commit | --pr <number>]",
+	Short: "Analyze a commit and publish the findings as an issue (GitHub or GitLab) or check run",
 	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		wd, err := ensureProjectRoot()
-		if err != nil {
-			return err
+		if flagTriagePR > 0 && len(args) == 1 {
+			return fmt.Errorf("--pr cannot be combined with a commit")
 		}
-
-		// project.yaml
-		prj, err := project.Load(wd)
-		if err != nil {
-			return fmt.Errorf("project config not found — run 'dai init' first: %w", err)
-		}
-		if prj.Owner == "" || prj.Repo == "" {
-			return fmt.Errorf("project config missing owner/repo")
+		if flagTriageRepo != "" && flagTriagePR == 0 {
+			return fmt.Errorf("--repo requires --pr")
 		}
 
-		// GitHub token
-		token, err := config.LoadGitHubToken()
-		if err != nil || strings.TrimSpace(token) == "" {
-			return fmt.Errorf("GitHub token not found — run 'dai auth' first: %w", err)
+		var wd string
+		var prj *project.Project
+		var err error
+		if flagTriageRepo != "" {
+			// a remote PR needs no checkout; .daiignore is read if present
+			if wd, err = os.Getwd(); err != nil {
+				return err
+			}
+			if prj, err = repoFlagProject(flagTriageRepo); err != nil {
+				return err
+			}
+		} else {
+			if wd, err = ensureProjectRoot(); err != nil {
+				return err
+			}
+			if prj, err = loadProject(wd); err != nil {
+				return err
+			}
 		}
-		token = strings.TrimSpace(token)
 
-		// OpenAI config
-		cfg, err := config.Load()
+		base, err := triageOptions(cmd.Context(), wd, prj, flagModel)
 		if err != nil {
-			return fmt.Errorf("global config not found — run 'dai config' first: %w", err)
-		}
-		if flagModel != "" {
-			cfg.Model = flagModel
-		}
-		if strings.TrimSpace(cfg.OpenAIKey) == "" {
-			return fmt.Errorf("OpenAI key missing in global config — run 'dai config'")
+			return err
 		}
 
 		// Commit
@@ -82,20 +101,51 @@ var triageCmd = &cobra.Command{
 		// Include extensions
 		exts := splitCSV(flagTriageExt)
 
-		opts := triage.Options{
-			Root:         wd,
-			Owner:        prj.Owner,
-			Repo:         prj.Repo,
-			GitHubToken:  token,
-			OpenAIKey:    cfg.OpenAIKey,
-			Model:        cfg.Model,
-			Commit:       commit, // empty == HEAD
-			IncludeExts:  exts,
-			MaxFileBytes: int64(flagMaxKB) * 1024,
-			IgnoreFile:   filepath.Join(wd, flagIgnorePath),
-			DryRun:       flagTriageDryRun,
-			AlwaysOpen:   flagAlwaysOpen,
-			DiffContext:  flagDiffContext, // NEW
+		mergeMode, err := gitutil.ParseMergeMode(flagMergeMode)
+		if err != nil {
+			return err
+		}
+		assignAuthor, err := triage.ParseAssignAuthor(flagAssignAuthor)
+		if err != nil {
+			return err
+		}
+		publish, err := triage.ParsePublish(flagPublish)
+		if err != nil {
+			return err
+		}
+		failOn, err := triage.ParseSeverity(flagFailOn)
+		if err != nil {
+			return fmt.Errorf("--fail-on: %w", err)
+		}
+
+		opts := base
+		opts.Commit = commit // empty == HEAD
+		opts.PR = flagTriagePR
+		opts.IncludeExts = exts
+		opts.MaxFileBytes = int64(flagMaxKB) * 1024
+		opts.IgnoreFile = filepath.Join(wd, flagIgnorePath)
+		opts.DryRun = flagTriageDryRun
+		opts.AlwaysOpen = flagAlwaysOpen
+		opts.NewIssue = flagNewIssue
+		opts.DiffContext = flagDiffContext
+		opts.MergeMode = mergeMode
+		opts.AssignAuthor = assignAuthor
+		opts.Publish = publish
+		opts.FailOn = failOn
+		if slices.Contains(publish, triage.PublishJira) {
+			if err := triage.ValidateJira(prj.Jira); err != nil {
+				return err
+			}
+			if opts.JiraToken, err = providerToken(cmd.Context(), providerJira, urlHost(prj.Jira.URL)); err != nil {
+				return err
+			}
+			opts.Jira = prj.Jira
+		}
+		if !flagNoNotify {
+			if err := triage.ValidateNotify(prj.Notify); err != nil {
+				return err
+			}
+			opts.Notify = prj.Notify
 		}
 
 		result, err := triage.Run(cmd.Context(), opts)
@@ -107,15 +157,60 @@ var triageCmd = &cobra.Command{
 			fmt.Println(result.Body)
 			return nil
 		}
-		if result.Skipped {
-			fmt.Println("No findings from diff hunks. Skipped creating a GitHub issue. (use --always-open to force)")
-			return nil
-		}
-		fmt.Printf("✓ Issue #%d created: %s\n", result.Number, result.URL)
+		printTriageResult(result, opts.Publish)
+		printNotifications(result.Notifications)
 		return nil
 	},
 }
 
+func printTriageResult(result *triage.Result, publish []string) {
+	for _, target := range publish {
+		switch target {
+		case triage.PublishChecks:
+			fmt.Printf("✓ Check run %s created: %s\n", triage.CheckName, result.CheckURL)
+		case triage.PublishStatus:
+			fmt.Printf("✓ Commit status %s set\n", triage.CheckName)
+		case triage.PublishJira:
+			if len(result.JiraTickets) == 0 {
+				fmt.Println("No new findings for Jira. Skipped creating tickets.")
+			}
+			for _, t := range result.JiraTickets {
+				fmt.Printf("✓ Jira %s created (%d finding(s)): %s\n", t.Key, t.Findings, t.URL)
+			}
+		case triage.PublishCommitComment:
+			if result.CommitCommentURL == "" {
+				fmt.Println("No findings from diff hunks. Skipped the commit comment. (use --always-open to force)")
+			} else {
+				fmt.Printf("✓ Commit comment posted: %s\n", result.CommitCommentURL)
+			}
+		case triage.PublishIssue:
+			switch {
+			case result.Skipped:
+				fmt.Println("No findings from diff hunks. Skipped creating an issue. (use --always-open to force)")
+			case result.Updated && result.NewFindings == 0:
+				fmt.Printf("Issue #%d already lists these findings: %s\n", result.Number, result.URL)
+			case result.Updated:
+				fmt.Printf("✓ Issue #%d updated with %d new finding(s): %s\n", result.Number, result.NewFindings, result.URL)
+			default:
+				fmt.Printf("✓ Issue #%d created: %s\n", result.Number, result.URL)
+			}
+		}
+	}
+}
+
+func printNotifications(ns []triage.Notification) {
+	for _, n := range ns {
+		switch {
+		case n.Err != nil:
+			fmt.Printf("! Notification %s failed: %v\n", n.Sink, n.Err)
+		case n.Skipped:
+			fmt.Printf("Notification %s skipped: no finding passed its filter\n", n.Sink)
+		default:
+			fmt.Printf("✓ Notified %s\n", n.Sink)
+		}
+	}
+}
+
 func ensureProjectRoot() (string, error) {
 	wd, err := os.Getwd()
 	if err != nil {
@@ -123,6 +218,10 @@ func ensureProjectRoot() (string, error) {
 	}
 	p := filepath.Join(wd, ".dai", "project.yaml")
 	if _, statErr := os.Stat(p); statErr != nil {
+		if os.IsNotExist(statErr) && gitlabCI() {
+			// the project comes from the CI variables
+			return wd, nil
+		}
 		if os.IsNotExist(statErr) {
 			return "", fmt.Errorf("'.dai/project.yaml' not found in current directory (%s)\nRun 'dai triage' from the project root (where you ran 'dai init')", wd)
 		}
@@ -146,3 +245,16 @@ func splitCSV(csv string) []string {
 	}
 	return out
 }
+
+// repoFlagProject builds a project from --repo [host/]owner/name for runs
+// outside a dai project.
+func repoFlagProject(s string) (*project.Project, error) {
+	segs := strings.Split(strings.Trim(strings.TrimSpace(s), "/"), "/")
+	switch len(segs) {
+	case 2:
+		return &project.Project{Provider: "github", Owner: segs[0], Repo: segs[1]}, nil
+	case 3:
+		return &project.Project{Provider: "github", Host: segs[0], Owner: segs[1], Repo: segs[2]}, nil
+	}
+	return nil, fmt.Errorf("invalid --repo %q (use owner/name or host/owner/name)", s)
+}
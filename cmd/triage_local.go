@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,7 +10,6 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/gorankrgovic/dai/internal/config"
 	"github.com/gorankrgovic/dai/internal/triage"
 )
 
@@ -44,17 +42,11 @@ var triageLocalCmd = &cobra.Command{
 			return err
 		}
 
-		cfg, err := config.Load()
+		cfg, _, err := openAIConfig(flagLocalModel)
 		if err != nil {
-			return fmt.Errorf("global config not found — run 'dai config' first: %w", err)
+			return err
 		}
 		model := cfg.Model
-		if flagLocalModel != "" {
-			model = flagLocalModel
-		}
-		if strings.TrimSpace(cfg.OpenAIKey) == "" {
-			return errors.New("OpenAI key missing — run 'dai config'")
-		}
 
 		p := args[0]
 		if !filepath.IsAbs(p) {
@@ -73,54 +65,11 @@ var triageLocalCmd = &cobra.Command{
 			return err
 		}
 
-		logPath := flagLocalLogPath
-		if !filepath.IsAbs(logPath) {
-			logPath = filepath.Join(root, logPath)
-		}
-		if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+		logPath := localLogPath(root, flagLocalLogPath)
+		if err := logLocalFinding(logPath, flagLocalFormat, relOrSame(root, p), model, truncated, finding); err != nil {
 			return err
 		}
 
-		now := time.Now().Format(time.RFC3339)
-		switch strings.ToLower(flagLocalFormat) {
-		case "json":
-			entry := map[string]any{
-				"time":      now,
-				"file":      relOrSame(root, p),
-				"model":     model,
-				"truncated": truncated,
-				"type":      finding.Type,
-				"title":     finding.Title,
-				"severity":  finding.Severity,
-				"lineHints": finding.LineHints,
-				"details":   finding.Details,
-			}
-			b, _ := json.Marshal(entry)
-			if err := appendLine(logPath, string(b)); err != nil {
-				return err
-			}
-		default: // md
-			var sb strings.Builder
-			fmt.Fprintf(&sb, "### %s — %s (model: %s, truncated: %v)\n", now, relOrSame(root, p), model, truncated)
-			fmt.Fprintf(&sb, "- Type: **%s**\n", strings.ToUpper(finding.Type))
-			if finding.Title != "" {
-				fmt.Fprintf(&sb, "- Title: %s\n", finding.Title)
-			}
-			if finding.Severity != "" {
-				fmt.Fprintf(&sb, "- Severity: %s\n", strings.ToUpper(finding.Severity))
-			}
-			if finding.LineHints != "" {
-				fmt.Fprintf(&sb, "- Lines: %s\n", finding.LineHints)
-			}
-			if finding.Details != "" {
-				fmt.Fprintf(&sb, "\n%s\n", finding.Details)
-			}
-			fmt.Fprintf(&sb, "\n---\n")
-			if err := appendLine(logPath, sb.String()); err != nil {
-				return err
-			}
-		}
-
 		if !flagLocalNoStdout {
 			fmt.Printf("File: %s\n", relOrSame(root, p))
 			fmt.Printf("Type: %s | Severity: %s\n", finding.Type, strings.ToUpper(finding.Severity))
@@ -139,6 +88,57 @@ var triageLocalCmd = &cobra.Command{
 	},
 }
 
+func localLogPath(root, p string) string {
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(root, p)
+	}
+	return p
+}
+
+// logLocalFinding appends a triage-local result for file (relative to the
+// project root) to the log in format md or json.
+func logLocalFinding(logPath, format, file, model string, truncated bool, finding triage.LocalFinding) error {
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+		return err
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	switch strings.ToLower(format) {
+	case "json":
+		entry := map[string]any{
+			"time":      now,
+			"file":      file,
+			"model":     model,
+			"truncated": truncated,
+			"type":      finding.Type,
+			"title":     finding.Title,
+			"severity":  finding.Severity,
+			"lineHints": finding.LineHints,
+			"details":   finding.Details,
+		}
+		b, _ := json.Marshal(entry)
+		return appendLine(logPath, string(b)+"\n")
+	default: // md
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "### %s — %s (model: %s, truncated: %v)\n", now, file, model, truncated)
+		fmt.Fprintf(&sb, "- Type: **%s**\n", strings.ToUpper(finding.Type))
+		if finding.Title != "" {
+			fmt.Fprintf(&sb, "- Title: %s\n", finding.Title)
+		}
+		if finding.Severity != "" {
+			fmt.Fprintf(&sb, "- Severity: %s\n", strings.ToUpper(finding.Severity))
+		}
+		if finding.LineHints != "" {
+			fmt.Fprintf(&sb, "- Lines: %s\n", finding.LineHints)
+		}
+		if finding.Details != "" {
+			fmt.Fprintf(&sb, "\n%s\n", finding.Details)
+		}
+		fmt.Fprintf(&sb, "\n---\n")
+		return appendLine(logPath, sb.String())
+	}
+}
+
 func appendLine(path, s string) error {
 	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
 	if err != nil {
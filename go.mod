@@ -7,6 +7,7 @@ require (
 	github.com/sabhiram/go-gitignore v0.0.0-20210923224102-525f6e181f06
 	github.com/sashabaranov/go-openai v1.41.0
 	github.com/spf13/cobra v1.9.1
+	golang.org/x/crypto v0.41.0
 	golang.org/x/term v0.34.0
 	gopkg.in/yaml.v3 v3.0.1
 )
@@ -19,5 +20,5 @@ require (
 	github.com/mgutz/ansi v0.0.0-20170206155736-9520e82c474b // indirect
 	github.com/spf13/pflag v1.0.6 // indirect
 	golang.org/x/sys v0.35.0 // indirect
-	golang.org/x/text v0.4.0 // indirect
+	golang.org/x/text v0.28.0 // indirect
 )
@@ -1,67 +1,282 @@
 package config
 
 import (
+	"context"
 	"errors"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gorankrgovic/dai/internal/secret"
 )
 
-func githubTokenPath() (string, error) {
+// Credential is a stored token for a service (github, gitlab, gitea, jira,
+// openai, anthropic) on a host. An empty Host is the service's default,
+// used for its canonical host (see DefaultApplies).
+type Credential struct {
+	Service string    `yaml:"service"`
+	Host    string    `yaml:"host,omitempty"`
+	Secret  string    `yaml:"secret"` // the token, or a secret store reference
+	Added   time.Time `yaml:"added,omitempty"`
+
+	// Legacy marks a ~/.dai/<service>_token file from before the
+	// credential store; it acts as the service's default.
+	Legacy bool `yaml:"-"`
+}
+
+// Where names the file a credential is kept in, plus its secret store
+// reference, for status output.
+func (c Credential) Where() string {
+	w := "~/.dai/credentials.yaml"
+	if c.Legacy {
+		w = "~/.dai/" + c.Service + "_token"
+	}
+	if secret.Backend(c.Secret) != secret.Plaintext {
+		w += " → " + c.Secret
+	}
+	return w
+}
+
+type credentialFile struct {
+	Credentials []Credential `yaml:"credentials"`
+}
+
+func credentialsPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "credentials.yaml"), nil
+}
+
+// tokenPath is where the token for provider ("github", "gitlab") was kept
+// before the credential store.
+func tokenPath(provider string) (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(home, ".dai", "github_token"), nil
+	return filepath.Join(home, ".dai", provider+"_token"), nil
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), "/")
+}
+
+func readCredentials() ([]Credential, error) {
+	p, err := credentialsPath()
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f credentialFile
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+	return f.Credentials, nil
 }
 
-func SaveGitHubToken(tok string) error {
-	p, err := githubTokenPath()
+func writeCredentials(creds []Credential) error {
+	p, err := credentialsPath()
 	if err != nil {
 		return err
 	}
-	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
 		return err
 	}
-	return os.WriteFile(p, []byte(tok), 0o600)
+	slices.SortFunc(creds, func(a, b Credential) int {
+		if c := strings.Compare(a.Service, b.Service); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Host, b.Host)
+	})
+	b, err := yaml.Marshal(&credentialFile{Credentials: creds})
+	if err != nil {
+		return err
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
 }
 
-func LoadGitHubToken() (string, error) {
-	p, err := githubTokenPath()
+func legacyCredential(service string) (Credential, bool) {
+	p, err := tokenPath(service)
 	if err != nil {
-		return "", err
+		return Credential{}, false
 	}
 	b, err := os.ReadFile(p)
+	if err != nil || strings.TrimSpace(string(b)) == "" {
+		return Credential{}, false
+	}
+	return Credential{Service: service, Secret: strings.TrimSpace(string(b)), Legacy: true}, true
+}
+
+// SaveCredential stores token for service on host (empty: the default) in
+// the configured secret store, replacing an earlier one.
+func SaveCredential(ctx context.Context, service, host, token string) error {
+	host = normalizeHost(host)
+	name := service
+	if host != "" {
+		name += "@" + host
+	}
+	ref, err := secret.Put(ctx, secretStore(), name, token)
 	if err != nil {
-		return "", err
+		return err
+	}
+	creds, err := readCredentials()
+	if err != nil {
+		return err
+	}
+	c := Credential{Service: service, Host: host, Secret: ref, Added: time.Now().UTC().Truncate(time.Second)}
+	var old string
+	if i := slices.IndexFunc(creds, func(c Credential) bool { return c.Service == service && c.Host == host }); i >= 0 {
+		old = creds[i].Secret
+		creds[i] = c
+	} else {
+		creds = append(creds, c)
+	}
+	if err := writeCredentials(creds); err != nil {
+		return err
+	}
+	if old != "" && old != ref {
+		_ = secret.Remove(ctx, old)
+	}
+	if legacy, ok := legacyCredential(service); ok && host == "" {
+		// the store's default supersedes the legacy file
+		if legacy.Secret != ref {
+			_ = secret.Remove(ctx, legacy.Secret)
+		}
+		if p, err := tokenPath(service); err == nil {
+			_ = os.Remove(p)
+		}
+	}
+	return nil
+}
+
+// canonicalHosts are the hosts a service's default credential is meant
+// for.
+var canonicalHosts = map[string]string{
+	"github": "github.com",
+	"gitlab": "gitlab.com",
+	"gitea":  "codeberg.org",
+}
+
+// DefaultApplies reports whether the default credential of service may be
+// sent to host: only to its canonical host, or when no host is given. The
+// host usually comes from the repository's project.yaml, which must not be
+// able to send the user's token anywhere else.
+func DefaultApplies(service, host string) bool {
+	host = normalizeHost(host)
+	return host == "" || host == canonicalHosts[service]
+}
+
+// FindCredential returns the credential for service on host: the host's
+// own, else (see DefaultApplies) the service default or a legacy token
+// file. It returns os.ErrNotExist when there is none.
+func FindCredential(service, host string) (Credential, error) {
+	host = normalizeHost(host)
+	creds, err := readCredentials()
+	if err != nil {
+		return Credential{}, err
+	}
+	hosts := []string{host}
+	if DefaultApplies(service, host) {
+		hosts = append(hosts, "")
+	}
+	for _, h := range hosts {
+		if i := slices.IndexFunc(creds, func(c Credential) bool { return c.Service == service && c.Host == h }); i >= 0 {
+			return creds[i], nil
+		}
+	}
+	if c, ok := legacyCredential(service); ok && DefaultApplies(service, host) {
+		return c, nil
 	}
-	return string(b), nil
+	return Credential{}, os.ErrNotExist
 }
 
-// DeleteGitHubToken removes the stored token file entirely.
-func DeleteGitHubToken() error {
-	p, err := githubTokenPath()
+// LoadCredential returns the token FindCredential picks, resolved.
+func LoadCredential(ctx context.Context, service, host string) (string, Credential, error) {
+	c, err := FindCredential(service, host)
+	if err != nil {
+		return "", c, err
+	}
+	tok, err := secret.Resolve(ctx, c.Secret)
+	if err != nil {
+		return "", c, err
+	}
+	return strings.TrimSpace(tok), c, nil
+}
+
+// DeleteCredential removes the credential for service on exactly host
+// (the default, and a legacy token file, for ""), and the secret it
+// refers to. It returns os.ErrNotExist when there was none.
+func DeleteCredential(ctx context.Context, service, host string) error {
+	host = normalizeHost(host)
+	creds, err := readCredentials()
 	if err != nil {
 		return err
 	}
-	// If it doesn't exist, return a friendly error so caller can surface it.
-	if _, statErr := os.Stat(p); errors.Is(statErr, os.ErrNotExist) {
+	found := false
+	if i := slices.IndexFunc(creds, func(c Credential) bool { return c.Service == service && c.Host == host }); i >= 0 {
+		if err := secret.Remove(ctx, creds[i].Secret); err != nil {
+			return err
+		}
+		creds = slices.Delete(creds, i, i+1)
+		if err := writeCredentials(creds); err != nil {
+			return err
+		}
+		found = true
+	}
+	if host == "" {
+		if c, ok := legacyCredential(service); ok {
+			if err := secret.Remove(ctx, c.Secret); err != nil {
+				return err
+			}
+			p, _ := tokenPath(service)
+			if err := os.Remove(p); err != nil {
+				return err
+			}
+			found = true
+		}
+	}
+	if !found {
 		return os.ErrNotExist
 	}
-	return os.Remove(p)
+	return nil
 }
 
-// GitHubTokenExists returns true if a non-empty token file is present.
-func GitHubTokenExists() (bool, error) {
-	p, err := githubTokenPath()
+// ListCredentials returns every stored credential, legacy token files
+// included, without resolving them.
+func ListCredentials() ([]Credential, error) {
+	creds, err := readCredentials()
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	b, err := os.ReadFile(p)
+	dir, err := configDir()
 	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return false, nil
+		return nil, err
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*_token"))
+	for _, f := range files {
+		service := strings.TrimSuffix(filepath.Base(f), "_token")
+		if slices.ContainsFunc(creds, func(c Credential) bool { return c.Service == service && c.Host == "" }) {
+			continue
+		}
+		if c, ok := legacyCredential(service); ok {
+			creds = append(creds, c)
 		}
-		return false, err
 	}
-	return len(b) > 0, nil
+	return creds, nil
 }
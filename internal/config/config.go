@@ -1,16 +1,26 @@
 package config
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/gorankrgovic/dai/internal/secret"
 )
 
 type Config struct {
-	OpenAIKey string `yaml:"openai_key"`
-	Model     string `yaml:"model"`
+	// OpenAIKeyRef is what config.yaml holds: the key, or a reference to
+	// it in a secret store. Load resolves it into OpenAIKey.
+	OpenAIKeyRef string `yaml:"openai_key"`
+	OpenAIKey    string `yaml:"-"`
+	Model        string `yaml:"model"`
+	// SecretStore is the backend new secrets are written to (see
+	// package secret); empty means plaintext.
+	SecretStore string `yaml:"secret_store,omitempty"`
 }
 
 func configDir() (string, error) {
@@ -29,7 +39,20 @@ func Path() (string, error) {
 	return filepath.Join(dir, "config.yaml"), nil
 }
 
+// Load reads config.yaml and resolves the OpenAI key.
 func Load() (*Config, error) {
+	c, err := Read()
+	if err != nil {
+		return nil, err
+	}
+	if c.OpenAIKey, err = secret.Resolve(context.Background(), c.OpenAIKeyRef); err != nil {
+		return nil, fmt.Errorf("openai_key: %w", err)
+	}
+	return c, nil
+}
+
+// Read reads config.yaml without resolving secrets, for editing it.
+func Read() (*Config, error) {
 	p, err := Path()
 	if err != nil {
 		return nil, err
@@ -48,6 +71,37 @@ func Load() (*Config, error) {
 	return &c, nil
 }
 
+// SetOpenAIKey stores key in the configured secret store, points
+// openai_key at it and saves the config. The old secret is removed only
+// once the config no longer refers to it.
+func (c *Config) SetOpenAIKey(ctx context.Context, key string) error {
+	ref, err := secret.Put(ctx, c.SecretStore, "openai_key", key)
+	if err != nil {
+		return err
+	}
+	old, oldKey := c.OpenAIKeyRef, c.OpenAIKey
+	c.OpenAIKeyRef, c.OpenAIKey = ref, key
+	if err := Save(c); err != nil {
+		c.OpenAIKeyRef, c.OpenAIKey = old, oldKey
+		if ref != old {
+			_ = secret.Remove(ctx, ref)
+		}
+		return err
+	}
+	if old != "" && old != ref {
+		_ = secret.Remove(ctx, old)
+	}
+	return nil
+}
+
+// secretStore is the configured backend, or plaintext without a config.
+func secretStore() string {
+	if c, err := Read(); err == nil {
+		return c.SecretStore
+	}
+	return secret.Plaintext
+}
+
 func Save(c *Config) error {
 	p, err := Path()
 	if err != nil {
@@ -63,3 +117,61 @@ func Save(c *Config) error {
 	}
 	return os.WriteFile(p, b, 0o600)
 }
+
+// MoveSecrets re-stores the OpenAI key and every stored credential in backend,
+// which becomes the store for new secrets, and returns what was moved.
+// Secrets behind exec references stay where they are.
+func MoveSecrets(ctx context.Context, backend string) ([]string, error) {
+	if !secret.Valid(backend) {
+		return nil, fmt.Errorf("unknown secret store %q", backend)
+	}
+	c, err := Read()
+	if errors.Is(err, os.ErrNotExist) {
+		c, err = &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.SecretStore = backend
+	if backend == secret.Plaintext {
+		c.SecretStore = ""
+	}
+
+	var moved []string
+	if c.OpenAIKeyRef != "" && secret.Backend(c.OpenAIKeyRef) != secret.Exec {
+		key, err := secret.Resolve(ctx, c.OpenAIKeyRef)
+		if err != nil {
+			return moved, fmt.Errorf("openai_key: %w", err)
+		}
+		if err := c.SetOpenAIKey(ctx, key); err != nil {
+			return moved, fmt.Errorf("openai_key: %w", err)
+		}
+		moved = append(moved, "openai_key")
+	}
+	if err := Save(c); err != nil {
+		return moved, err
+	}
+
+	creds, err := ListCredentials()
+	if err != nil {
+		return moved, err
+	}
+	for _, c := range creds {
+		if secret.Backend(c.Secret) == secret.Exec {
+			continue
+		}
+		name := c.Service
+		if c.Host != "" {
+			name += "@" + c.Host
+		}
+		tok, err := secret.Resolve(ctx, c.Secret)
+		if err != nil {
+			return moved, fmt.Errorf("%s: %w", name, err)
+		}
+		if err := SaveCredential(ctx, c.Service, c.Host, tok); err != nil {
+			return moved, fmt.Errorf("%s: %w", name, err)
+		}
+		moved = append(moved, name)
+	}
+	return moved, nil
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gorankrgovic/dai/internal/gh"
+)
+
+// GitHubApp holds the credentials of a GitHub App dai authenticates as
+// (~/.dai/github_app.yaml). The private key is copied next to it.
+type GitHubApp struct {
+	AppID          string `yaml:"app_id"`
+	InstallationID int64  `yaml:"installation_id,omitempty"` // 0: per repository
+	PrivateKeyPath string `yaml:"private_key_path"`
+}
+
+func githubAppPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "github_app.yaml"), nil
+}
+
+// SaveGitHubApp stores app and a copy of its PEM private key, both 0600.
+func SaveGitHubApp(app GitHubApp, key []byte) error {
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	app.PrivateKeyPath = filepath.Join(dir, "github_app.pem")
+	if err := os.WriteFile(app.PrivateKeyPath, key, 0o600); err != nil {
+		return err
+	}
+	b, err := yaml.Marshal(&app)
+	if err != nil {
+		return err
+	}
+	p, err := githubAppPath()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, b, 0o600)
+}
+
+// LoadGitHubApp returns os.ErrNotExist when no app is configured.
+func LoadGitHubApp() (*GitHubApp, error) {
+	p, err := githubAppPath()
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	var app GitHubApp
+	if err := yaml.Unmarshal(b, &app); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// DeleteGitHubApp removes the app config, its key and cached tokens.
+func DeleteGitHubApp() error {
+	app, err := LoadGitHubApp()
+	if err != nil {
+		return err
+	}
+	p, _ := githubAppPath()
+	if err := os.Remove(p); err != nil {
+		return err
+	}
+	if app.PrivateKeyPath != "" {
+		_ = os.Remove(app.PrivateKeyPath)
+	}
+	if c, err := appTokenCachePath(); err == nil {
+		_ = os.Remove(c)
+	}
+	return nil
+}
+
+func appTokenCachePath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "github_app_tokens.json"), nil
+}
+
+// AppTokenCache keeps installation tokens in ~/.dai/github_app_tokens.json
+// so consecutive runs (e.g. git hooks) do not mint a token each.
+type AppTokenCache struct {
+	mu sync.Mutex
+}
+
+var _ gh.TokenCache = (*AppTokenCache)(nil)
+
+func (c *AppTokenCache) read() map[string]gh.InstallationToken {
+	m := map[string]gh.InstallationToken{}
+	p, err := appTokenCachePath()
+	if err != nil {
+		return m
+	}
+	if b, err := os.ReadFile(p); err == nil {
+		_ = json.Unmarshal(b, &m)
+	}
+	return m
+}
+
+func (c *AppTokenCache) Load(key string) (gh.InstallationToken, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.read()[key]
+	return t, ok
+}
+
+func (c *AppTokenCache) Save(key string, t gh.InstallationToken) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m := c.read()
+	if t.Token == "" {
+		delete(m, key)
+	} else {
+		m[key] = t
+	}
+	p, err := appTokenCachePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+// GitHubAppConfigured reports whether an app is stored.
+func GitHubAppConfigured() bool {
+	_, err := LoadGitHubApp()
+	return err == nil || !errors.Is(err, os.ErrNotExist)
+}
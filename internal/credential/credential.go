@@ -0,0 +1,78 @@
+// Package credential finds tokens where other tools already keep them:
+// environment variables, the gh CLI and git credential helpers.
+package credential
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// lookupTimeout bounds each external helper, which may be slow (keychain
+// unlock) or misconfigured.
+const lookupTimeout = 10 * time.Second
+
+// Credential is a secret and where it was found.
+type Credential struct {
+	Secret string
+	Source string // human-readable, e.g. "GITHUB_TOKEN" or "gh CLI"
+}
+
+// Env returns the first non-empty variable of names.
+func Env(names ...string) (Credential, bool) {
+	for _, n := range names {
+		if v := strings.TrimSpace(os.Getenv(n)); v != "" {
+			return Credential{Secret: v, Source: n}, true
+		}
+	}
+	return Credential{}, false
+}
+
+// GhCLI returns the token the gh CLI is logged in with for host.
+func GhCLI(ctx context.Context, host string) (Credential, bool) {
+	if host == "" {
+		host = "github.com"
+	}
+	if _, err := exec.LookPath("gh"); err != nil {
+		return Credential{}, false
+	}
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "gh", "auth", "token", "--hostname", host).Output()
+	if err != nil {
+		return Credential{}, false
+	}
+	tok := strings.TrimSpace(string(out))
+	if tok == "" {
+		return Credential{}, false
+	}
+	return Credential{Secret: tok, Source: "gh CLI (" + host + ")"}, true
+}
+
+// GitCredential asks the configured git credential helpers for the
+// password of https://host. It never prompts.
+func GitCredential(ctx context.Context, host string) (Credential, bool) {
+	if host == "" {
+		return Credential{}, false
+	}
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", "-c", "credential.interactive=false", "credential", "fill")
+	cmd.Stdin = strings.NewReader("protocol=https\nhost=" + host + "\n\n")
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never", "GIT_ASKPASS=", "SSH_ASKPASS=")
+	out, err := cmd.Output()
+	if err != nil {
+		return Credential{}, false
+	}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		if pw, ok := strings.CutPrefix(sc.Text(), "password="); ok && strings.TrimSpace(pw) != "" {
+			return Credential{Secret: strings.TrimSpace(pw), Source: "git credential helper (" + host + ")"}, true
+		}
+	}
+	return Credential{}, false
+}
@@ -0,0 +1,51 @@
+package credential
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const verifyTimeout = 15 * time.Second
+
+// VerifyOpenAI checks an OpenAI API key by listing the models it can use.
+func VerifyOpenAI(ctx context.Context, key string) error {
+	return probe(ctx, "https://api.openai.com/v1/models", map[string]string{
+		"Authorization": "Bearer " + key,
+	})
+}
+
+// VerifyAnthropic checks an Anthropic API key by listing the models it
+// can use.
+func VerifyAnthropic(ctx context.Context, key string) error {
+	return probe(ctx, "https://api.anthropic.com/v1/models", map[string]string{
+		"x-api-key":         key,
+		"anthropic-version": "2023-06-01",
+	})
+}
+
+func probe(ctx context.Context, url string, headers map[string]string) error {
+	ctx, cancel := context.WithTimeout(ctx, verifyTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("User-Agent", "dai-cli/auth")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return fmt.Errorf("GET %s: %d: %s", url, resp.StatusCode, strings.TrimSpace(string(raw)))
+}
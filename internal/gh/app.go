@@ -0,0 +1,209 @@
+package gh
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// App JWTs may live at most 10 minutes; iat is backdated against clock
+// drift, as GitHub recommends.
+const (
+	appJWTLifetime = 9 * time.Minute
+	appJWTBackdate = 60 * time.Second
+
+	// tokenRefreshMargin is how long before expiry an installation token
+	// is replaced, so a request never starts with one about to lapse.
+	tokenRefreshMargin = 5 * time.Minute
+)
+
+// InstallationToken is an access token of a GitHub App installation. It
+// expires after an hour.
+type InstallationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (t InstallationToken) fresh(now time.Time) bool {
+	return t.Token != "" && now.Add(tokenRefreshMargin).Before(t.ExpiresAt)
+}
+
+// TokenCache keeps installation tokens between runs.
+type TokenCache interface {
+	Load(key string) (InstallationToken, bool)
+	Save(key string, t InstallationToken) error
+}
+
+// AppTokenSource authenticates as a GitHub App installation: it signs a
+// JWT with the app's private key and exchanges it for an installation
+// token, which it reuses until shortly before it expires.
+type AppTokenSource struct {
+	AppID string
+	Key   *rsa.PrivateKey
+	// InstallationID may be 0; the installation is then looked up from
+	// Owner/Repo.
+	InstallationID int64
+	Owner, Repo    string
+	Cache          TokenCache // optional
+
+	app *Client
+	mu  sync.Mutex
+	tok InstallationToken
+}
+
+// NewAppTokenSource creates a source for the app's installation on base
+// (empty means github.com).
+func NewAppTokenSource(base, appID string, key *rsa.PrivateKey, installationID int64, owner, repo string) *AppTokenSource {
+	s := &AppTokenSource{AppID: appID, Key: key, InstallationID: installationID, Owner: owner, Repo: repo}
+	s.app = NewClientWithTokenSource(base, appJWT{s})
+	s.app.scheme = "Bearer"
+	return s
+}
+
+// appJWT makes app-level requests carry a fresh JWT.
+type appJWT struct{ s *AppTokenSource }
+
+func (a appJWT) Token(context.Context) (string, error) { return a.s.JWT(time.Now()) }
+
+// ParsePrivateKey reads a PEM private key as downloaded from the app
+// settings (PKCS#1) or converted to PKCS#8.
+func ParsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("private key: no PEM block found")
+	}
+	if k, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return k, nil
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("private key: %w", err)
+	}
+	rk, ok := k.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key: not an RSA key")
+	}
+	return rk, nil
+}
+
+// JWT returns an RS256 token identifying the app, valid from now.
+func (s *AppTokenSource) JWT(now time.Time) (string, error) {
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-appJWTBackdate).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": s.AppID,
+	})
+	if err != nil {
+		return "", err
+	}
+	signing := header + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(signing))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.Key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", fmt.Errorf("sign app JWT: %w", err)
+	}
+	return signing + "." + enc.EncodeToString(sig), nil
+}
+
+// Token returns a valid installation token from memory, the cache or a new
+// exchange.
+func (s *AppTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if s.tok.fresh(now) {
+		return s.tok.Token, nil
+	}
+	key := s.cacheKey()
+	if s.Cache != nil {
+		if t, ok := s.Cache.Load(key); ok && t.fresh(now) {
+			s.tok = t
+			return t.Token, nil
+		}
+	}
+	t, err := s.exchange(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.tok = t
+	if s.Cache != nil {
+		// a cache we cannot write only costs another exchange next run
+		_ = s.Cache.Save(key, t)
+	}
+	return t.Token, nil
+}
+
+// Invalidate drops the current token, e.g. after GitHub rejected it.
+func (s *AppTokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Cache != nil && s.tok.Token != "" {
+		_ = s.Cache.Save(s.cacheKey(), InstallationToken{})
+	}
+	s.tok = InstallationToken{}
+}
+
+func (s *AppTokenSource) cacheKey() string {
+	if s.InstallationID > 0 {
+		return fmt.Sprintf("%s app=%s installation=%d", s.app.api.Base, s.AppID, s.InstallationID)
+	}
+	return fmt.Sprintf("%s app=%s repo=%s/%s", s.app.api.Base, s.AppID, s.Owner, s.Repo)
+}
+
+func (s *AppTokenSource) exchange(ctx context.Context) (InstallationToken, error) {
+	id := s.InstallationID
+	if id == 0 {
+		var inst struct {
+			ID int64 `json:"id"`
+		}
+		if _, err := s.app.api.Do(ctx, http.MethodGet, repoPath(s.Owner, s.Repo)+"/installation", nil, &inst); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return InstallationToken{}, fmt.Errorf("GitHub App %s is not installed on %s/%s: %w", s.AppID, s.Owner, s.Repo, err)
+			}
+			return InstallationToken{}, fmt.Errorf("find app installation: %w", err)
+		}
+		id = inst.ID
+	}
+	var t InstallationToken
+	path := "/app/installations/" + strconv.FormatInt(id, 10) + "/access_tokens"
+	if _, err := s.app.api.Do(ctx, http.MethodPost, path, nil, &t); err != nil {
+		return InstallationToken{}, fmt.Errorf("create installation token: %w", err)
+	}
+	return t, nil
+}
+
+// App describes the authenticated app (GET /app).
+type App struct {
+	ID   int64  `json:"id"`
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+// App checks the app credentials and returns the app they belong to.
+func (s *AppTokenSource) App(ctx context.Context) (*App, error) {
+	var out App
+	if _, err := s.app.api.Do(ctx, http.MethodGet, "/app", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// BotLogin is the login issues created through the app are attributed to.
+func (a *App) BotLogin() string {
+	return strings.ToLower(a.Slug) + "[bot]"
+}
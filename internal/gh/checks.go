@@ -0,0 +1,63 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// MaxAnnotations is how many annotations one check run request may carry;
+// further batches are added by updating the run.
+const MaxAnnotations = 50
+
+// MaxCheckSummary is the longest summary the Checks API accepts.
+const MaxCheckSummary = 65535
+
+type Annotation struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Level     string `json:"annotation_level"` // notice|warning|failure
+	Title     string `json:"title,omitempty"`
+	Message   string `json:"message"`
+}
+
+type CheckOutput struct {
+	Title       string       `json:"title"`
+	Summary     string       `json:"summary"`
+	Annotations []Annotation `json:"annotations,omitempty"`
+}
+
+type checkRunReq struct {
+	Name       string       `json:"name,omitempty"`
+	HeadSHA    string       `json:"head_sha,omitempty"`
+	Status     string       `json:"status,omitempty"`
+	Conclusion string       `json:"conclusion,omitempty"`
+	Output     *CheckOutput `json:"output,omitempty"`
+}
+
+type CheckRun struct {
+	ID      int64  `json:"id"`
+	HTMLURL string `json:"html_url"`
+}
+
+// CreateCheckRun creates a completed check run on sha. Annotations beyond
+// MaxAnnotations are added in follow-up updates.
+func (c *Client) CreateCheckRun(ctx context.Context, owner, repo, name, sha, conclusion string, out CheckOutput) (*CheckRun, error) {
+	all := out.Annotations
+	first := CheckOutput{Title: out.Title, Summary: out.Summary, Annotations: all[:min(len(all), MaxAnnotations)]}
+	req := checkRunReq{Name: name, HeadSHA: sha, Status: "completed", Conclusion: conclusion, Output: &first}
+	var run CheckRun
+	if _, err := c.api.Do(ctx, http.MethodPost, repoPath(owner, repo)+"/check-runs", req, &run); err != nil {
+		return nil, fmt.Errorf("create check run: %w", err)
+	}
+	path := repoPath(owner, repo) + "/check-runs/" + strconv.FormatInt(run.ID, 10)
+	for i := MaxAnnotations; i < len(all); i += MaxAnnotations {
+		batch := CheckOutput{Title: out.Title, Summary: out.Summary, Annotations: all[i:min(len(all), i+MaxAnnotations)]}
+		if _, err := c.api.Do(ctx, http.MethodPatch, path, checkRunReq{Output: &batch}, nil); err != nil {
+			return nil, fmt.Errorf("add check run annotations: %w", err)
+		}
+	}
+	return &run, nil
+}
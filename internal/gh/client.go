@@ -0,0 +1,153 @@
+package gh
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorankrgovic/dai/internal/rest"
+)
+
+// DefaultAPIBase is the API of github.com.
+const DefaultAPIBase = "https://api.github.com"
+
+// Typed errors, matched with errors.Is against an *APIError.
+var (
+	ErrUnauthorized = rest.Status(http.StatusUnauthorized, "github: unauthorized (check the token)")
+	ErrForbidden    = rest.Status(http.StatusForbidden, "github: forbidden (missing scope or permission)")
+	ErrNotFound     = rest.Status(http.StatusNotFound, "github: not found")
+	ErrValidation   = rest.Status(http.StatusUnprocessableEntity, "github: validation failed")
+)
+
+// APIError is a non-2xx response from the API.
+type APIError = rest.APIError
+
+// RateLimitError is returned when the limit resets too far in the future
+// to wait for it.
+type RateLimitError struct {
+	Reset     time.Time
+	Secondary bool
+}
+
+func (e *RateLimitError) Error() string {
+	kind := "rate limit"
+	if e.Secondary {
+		kind = "secondary rate limit"
+	}
+	return fmt.Sprintf("github: %s exceeded, resets at %s", kind, e.Reset.Format(time.RFC3339))
+}
+
+// TokenSource supplies the token sent with each request. Sources whose
+// tokens expire (GitHub App installations) refresh them as needed.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// invalidator is implemented by sources that can drop a token GitHub
+// rejected, so the next Token call fetches a new one.
+type invalidator interface {
+	Invalidate()
+}
+
+type staticToken string
+
+func (t staticToken) Token(context.Context) (string, error) { return string(t), nil }
+
+// Client talks to github.com or a GitHub Enterprise Server.
+type Client struct {
+	api    *rest.Client
+	tokens TokenSource
+	scheme string // Authorization scheme: "token", or "Bearer" for app JWTs
+}
+
+// NewClient creates a client with a fixed token (e.g. a personal access
+// token). An empty base means github.com.
+func NewClient(base, token string) *Client {
+	return NewClientWithTokenSource(base, staticToken(strings.TrimSpace(token)))
+}
+
+// NewClientWithTokenSource creates a client that asks src for the token of
+// every request.
+func NewClientWithTokenSource(base string, src TokenSource) *Client {
+	if base == "" {
+		base = DefaultAPIBase
+	}
+	c := &Client{tokens: src, scheme: "token"}
+	c.api = rest.New("github", base, c.auth)
+	c.api.Header = http.Header{
+		"Accept":               {"application/vnd.github+json"},
+		"X-Github-Api-Version": {"2022-11-28"},
+	}
+	c.api.RateLimited = func(resp *http.Response, body []byte) (time.Duration, bool, error) {
+		if wait, rlErr := rateLimitWait(resp, body); rlErr != nil {
+			return wait, true, rlErr
+		}
+		return 0, false, nil
+	}
+	// a revoked or expired installation token: fetch a new one once
+	c.api.Unauthorized = func() bool {
+		inv, ok := c.tokens.(invalidator)
+		if ok {
+			inv.Invalidate()
+		}
+		return ok
+	}
+	return c
+}
+
+func (c *Client) auth(ctx context.Context, h http.Header) error {
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		h.Set("Authorization", c.scheme+" "+token)
+	}
+	return nil
+}
+
+// APIBase returns the REST API root for a git host: api.github.com for
+// github.com, api.<host> for GHE.com data residency, and <host>/api/v3 for
+// GitHub Enterprise Server.
+func APIBase(host string) string {
+	h := strings.ToLower(strings.TrimSpace(host))
+	switch {
+	case h == "" || h == "github.com":
+		return DefaultAPIBase
+	case strings.HasSuffix(h, ".ghe.com"):
+		return "https://api." + h
+	default:
+		return "https://" + h + "/api/v3"
+	}
+}
+
+// rateLimitWait recognizes primary and secondary rate limit responses and
+// returns how long to wait before retrying.
+func rateLimitWait(resp *http.Response, body []byte) (time.Duration, *RateLimitError) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, nil
+	}
+	secondary := bytes.Contains(bytes.ToLower(body), []byte("secondary rate limit"))
+	if ra := resp.Header.Get("Retry-After"); ra != "" {
+		if secs, err := strconv.Atoi(ra); err == nil {
+			wait := time.Duration(secs) * time.Second
+			return wait, &RateLimitError{Reset: time.Now().Add(wait), Secondary: secondary}
+		}
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		reset := time.Now().Add(time.Minute)
+		if v, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			reset = time.Unix(v, 0)
+		}
+		return time.Until(reset) + time.Second, &RateLimitError{Reset: reset}
+	}
+	if secondary {
+		// no hint given: GitHub asks to wait at least a minute
+		return time.Minute, &RateLimitError{Reset: time.Now().Add(time.Minute), Secondary: true}
+	}
+	return 0, nil
+}
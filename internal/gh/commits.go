@@ -0,0 +1,43 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// MaxStatusDescription is the longest description a commit status takes.
+const MaxStatusDescription = 140
+
+type statusReq struct {
+	State       string `json:"state"`
+	TargetURL   string `json:"target_url,omitempty"`
+	Description string `json:"description,omitempty"`
+	Context     string `json:"context"`
+}
+
+// CreateStatus sets the commit status of sha for statusContext. state is
+// one of error, failure, pending or success.
+func (c *Client) CreateStatus(ctx context.Context, owner, repo, sha, state, statusContext, description, targetURL string) error {
+	if r := []rune(description); len(r) > MaxStatusDescription {
+		description = string(r[:MaxStatusDescription-1]) + "…"
+	}
+	req := statusReq{State: state, TargetURL: targetURL, Description: description, Context: statusContext}
+	if _, err := c.api.Do(ctx, http.MethodPost, repoPath(owner, repo)+"/statuses/"+url.PathEscape(sha), req, nil); err != nil {
+		return fmt.Errorf("create commit status: %w", err)
+	}
+	return nil
+}
+
+// CreateCommitComment comments on sha and returns the comment URL.
+func (c *Client) CreateCommitComment(ctx context.Context, owner, repo, sha, body string) (string, error) {
+	var out struct {
+		HTMLURL string `json:"html_url"`
+	}
+	path := repoPath(owner, repo) + "/commits/" + url.PathEscape(sha) + "/comments"
+	if _, err := c.api.Do(ctx, http.MethodPost, path, map[string]string{"body": body}, &out); err != nil {
+		return "", fmt.Errorf("create commit comment: %w", err)
+	}
+	return out.HTMLURL, nil
+}
@@ -1,13 +1,14 @@
 package gh
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
-	"time"
+
+	"github.com/gorankrgovic/dai/internal/rest"
 )
 
 type label struct {
@@ -16,9 +17,10 @@ type label struct {
 }
 
 type issueReq struct {
-	Title  string   `json:"title"`
-	Body   string   `json:"body,omitempty"`
-	Labels []string `json:"labels,omitempty"`
+	Title     string   `json:"title"`
+	Body      string   `json:"body,omitempty"`
+	Labels    []string `json:"labels,omitempty"`
+	Assignees []string `json:"assignees,omitempty"`
 }
 
 type issueResp struct {
@@ -26,104 +28,85 @@ type issueResp struct {
 	Number  int    `json:"number"`
 }
 
-func CreateIssue(ctx context.Context, owner, repo, token, title, body string, labels []string) (string, int, error) {
-	endpoint := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues", owner, repo)
-	reqBody := issueReq{Title: title, Body: body, Labels: labels}
-	b, _ := json.Marshal(reqBody)
+func repoPath(owner, repo string) string {
+	return "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(b))
-	if err != nil {
-		return "", 0, err
+// CreateIssue opens an issue. Assignees GitHub refuses (e.g. non
+// collaborators) make it retry once without assignees.
+func (c *Client) CreateIssue(ctx context.Context, owner, repo, title, body string, labels, assignees []string) (string, int, error) {
+	req := issueReq{Title: title, Body: body, Labels: labels, Assignees: assignees}
+	var out issueResp
+	_, err := c.api.Do(ctx, http.MethodPost, repoPath(owner, repo)+"/issues", req, &out)
+	if errors.Is(err, ErrValidation) && len(assignees) > 0 {
+		req.Assignees = nil
+		_, err = c.api.Do(ctx, http.MethodPost, repoPath(owner, repo)+"/issues", req, &out)
 	}
-	setCommonHeaders(req, token)
-
-	hc := &http.Client{Timeout: 20 * time.Second}
-	resp, err := hc.Do(req)
 	if err != nil {
 		return "", 0, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated {
-		var buf bytes.Buffer
-		buf.ReadFrom(resp.Body)
-		return "", 0, fmt.Errorf("github create issue: %d: %s", resp.StatusCode, buf.String())
-	}
-	var out issueResp
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return "", 0, err
-	}
 	return out.HTMLURL, out.Number, nil
 }
 
-func EnsureLabels(ctx context.Context, owner, repo, token string, labels []string) error {
+// EnsureLabels creates the labels the repository does not have yet, with
+// colors (hex, no '#') from color.
+func (c *Client) EnsureLabels(ctx context.Context, owner, repo string, labels []string, color func(string) string) error {
 	if len(labels) == 0 {
 		return nil
 	}
-	existing, err := listLabels(ctx, owner, repo, token)
+	existing, err := c.listLabels(ctx, owner, repo)
 	if err != nil {
 		return err
 	}
 	need := missing(labels, existing)
 	for _, name := range need {
-		if err := createLabel(ctx, owner, repo, token, name, defaultColor(name)); err != nil {
+		if err := c.createLabel(ctx, owner, repo, name, color(name)); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func listLabels(ctx context.Context, owner, repo, token string) (map[string]struct{}, error) {
-	endpoint := fmt.Sprintf("https://api.github.com/repos/%s/%s/labels?per_page=100", owner, repo)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
-	if err != nil {
-		return nil, err
-	}
-	setCommonHeaders(req, token)
-	hc := &http.Client{Timeout: 15 * time.Second}
-	resp, err := hc.Do(req)
+func (c *Client) listLabels(ctx context.Context, owner, repo string) (map[string]struct{}, error) {
+	set := map[string]struct{}{}
+	err := rest.GetPages(ctx, c.api, repoPath(owner, repo)+"/labels?per_page=100", func(page []label) error {
+		for _, l := range page {
+			set[strings.ToLower(strings.TrimSpace(l.Name))] = struct{}{}
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		var buf bytes.Buffer
-		buf.ReadFrom(resp.Body)
-		return nil, fmt.Errorf("github list labels: %d: %s", resp.StatusCode, buf.String())
-	}
-	var arr []label
-	if err := json.NewDecoder(resp.Body).Decode(&arr); err != nil {
-		return nil, err
-	}
-	set := make(map[string]struct{}, len(arr))
-	for _, l := range arr {
-		set[strings.ToLower(strings.TrimSpace(l.Name))] = struct{}{}
+		return nil, fmt.Errorf("list labels: %w", err)
 	}
 	return set, nil
 }
 
-func createLabel(ctx context.Context, owner, repo, token, name, color string) error {
-	endpoint := fmt.Sprintf("https://api.github.com/repos/%s/%s/labels", owner, repo)
-	body := label{Name: name, Color: color}
-	b, _ := json.Marshal(body)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(b))
-	if err != nil {
-		return err
+func (c *Client) createLabel(ctx context.Context, owner, repo, name, color string) error {
+	_, err := c.api.Do(ctx, http.MethodPost, repoPath(owner, repo)+"/labels", label{Name: name, Color: color}, nil)
+	// 422 means it exists with different case, which is fine
+	if err != nil && !errors.Is(err, ErrValidation) {
+		return fmt.Errorf("create label: %w", err)
 	}
-	setCommonHeaders(req, token)
-	hc := &http.Client{Timeout: 15 * time.Second}
-	resp, err := hc.Do(req)
-	if err != nil {
-		return err
+	return nil
+}
+
+type commitResp struct {
+	Author *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+}
+
+// CommitAuthorLogin returns the GitHub login GitHub associated with the
+// author of sha, or "" when the author email is not linked to an account.
+func (c *Client) CommitAuthorLogin(ctx context.Context, owner, repo, sha string) (string, error) {
+	var out commitResp
+	if _, err := c.api.Do(ctx, http.MethodGet, repoPath(owner, repo)+"/commits/"+url.PathEscape(sha), nil, &out); err != nil {
+		return "", err
 	}
-	defer resp.Body.Close()
-	// 201 Created or 422 Unprocessable (exists with different case) both OK
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusUnprocessableEntity {
-		var buf bytes.Buffer
-		buf.ReadFrom(resp.Body)
-		return fmt.Errorf("github create label: %d: %s", resp.StatusCode, buf.String())
+	if out.Author == nil {
+		return "", nil
 	}
-	return nil
+	return out.Author.Login, nil
 }
 
 func missing(want []string, have map[string]struct{}) []string {
@@ -135,23 +118,3 @@ func missing(want []string, have map[string]struct{}) []string {
 	}
 	return out
 }
-
-func defaultColor(name string) string {
-	switch strings.ToLower(strings.TrimSpace(name)) {
-	case "bug":
-		return "d73a4a"
-	case "enhancement":
-		return "a2eeef"
-	case "question":
-		return "d876e3"
-	default:
-		return "cccccc"
-	}
-}
-
-func setCommonHeaders(req *http.Request, token string) {
-	req.Header.Set("Authorization", "token "+token)
-	req.Header.Set("Accept", "application/vnd.github+json")
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "dai-cli/triage")
-}
@@ -0,0 +1,132 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type graphqlReq struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+type graphqlResp struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// graphqlURL derives the GraphQL endpoint from the REST root:
+// api.github.com/graphql, or <host>/api/graphql on Enterprise Server.
+func (c *Client) graphqlURL() string {
+	if b, ok := strings.CutSuffix(c.api.Base, "/api/v3"); ok {
+		return b + "/api/graphql"
+	}
+	return c.api.Base + "/graphql"
+}
+
+func (c *Client) graphql(ctx context.Context, query string, vars map[string]any, out any) error {
+	var resp graphqlResp
+	if _, err := c.api.Do(ctx, http.MethodPost, c.graphqlURL(), graphqlReq{Query: query, Variables: vars}, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		msgs := make([]string, len(resp.Errors))
+		for i, e := range resp.Errors {
+			msgs[i] = e.Message
+		}
+		return fmt.Errorf("github graphql: %s", strings.Join(msgs, "; "))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(resp.Data, out)
+}
+
+// ReviewThread is a pull request review thread, identified by the
+// database ID of its first comment.
+type ReviewThread struct {
+	ID             string
+	Resolved       bool
+	FirstCommentID int64
+}
+
+const reviewThreadsQuery = `query($owner: String!, $name: String!, $number: Int!, $cursor: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 100, after: $cursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          id
+          isResolved
+          comments(first: 1) { nodes { databaseId } }
+        }
+      }
+    }
+  }
+}`
+
+func (c *Client) ListReviewThreads(ctx context.Context, owner, repo string, number int) ([]ReviewThread, error) {
+	var out []ReviewThread
+	var cursor *string
+	for {
+		var data struct {
+			Repository struct {
+				PullRequest struct {
+					ReviewThreads struct {
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+						Nodes []struct {
+							ID         string `json:"id"`
+							IsResolved bool   `json:"isResolved"`
+							Comments   struct {
+								Nodes []struct {
+									DatabaseID int64 `json:"databaseId"`
+								} `json:"nodes"`
+							} `json:"comments"`
+						} `json:"nodes"`
+					} `json:"reviewThreads"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		}
+		vars := map[string]any{"owner": owner, "name": repo, "number": number, "cursor": cursor}
+		if err := c.graphql(ctx, reviewThreadsQuery, vars, &data); err != nil {
+			return nil, fmt.Errorf("list review threads: %w", err)
+		}
+		threads := data.Repository.PullRequest.ReviewThreads
+		for _, n := range threads.Nodes {
+			t := ReviewThread{ID: n.ID, Resolved: n.IsResolved}
+			if len(n.Comments.Nodes) > 0 {
+				t.FirstCommentID = n.Comments.Nodes[0].DatabaseID
+			}
+			out = append(out, t)
+		}
+		if !threads.PageInfo.HasNextPage {
+			return out, nil
+		}
+		end := threads.PageInfo.EndCursor
+		cursor = &end
+	}
+}
+
+func (c *Client) ResolveReviewThread(ctx context.Context, threadID string) error {
+	const q = `mutation($id: ID!) { resolveReviewThread(input: {threadId: $id}) { thread { id } } }`
+	if err := c.graphql(ctx, q, map[string]any{"id": threadID}, nil); err != nil {
+		return fmt.Errorf("resolve review thread: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) UnresolveReviewThread(ctx context.Context, threadID string) error {
+	const q = `mutation($id: ID!) { unresolveReviewThread(input: {threadId: $id}) { thread { id } } }`
+	if err := c.graphql(ctx, q, map[string]any{"id": threadID}, nil); err != nil {
+		return fmt.Errorf("unresolve review thread: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gorankrgovic/dai/internal/rest"
+)
+
+type Issue struct {
+	Number      int       `json:"number"`
+	Title       string    `json:"title"`
+	Body        string    `json:"body"`
+	State       string    `json:"state"`
+	HTMLURL     string    `json:"html_url"`
+	PullRequest *struct{} `json:"pull_request"`
+}
+
+func issuePath(owner, repo string, number int) string {
+	return repoPath(owner, repo) + "/issues/" + strconv.Itoa(number)
+}
+
+// ListIssues pages through the issues in state (open|closed|all), newest
+// first, calling fn for each. Pull requests are skipped. fn returns false to
+// stop early.
+func (c *Client) ListIssues(ctx context.Context, owner, repo, state string, fn func(Issue) bool) error {
+	q := url.Values{"state": {state}, "per_page": {"100"}, "sort": {"created"}, "direction": {"desc"}}
+	err := rest.GetPages(ctx, c.api, repoPath(owner, repo)+"/issues?"+q.Encode(), func(page []Issue) error {
+		for _, is := range page {
+			if is.PullRequest != nil {
+				continue
+			}
+			if !fn(is) {
+				return rest.ErrStopPaging
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("list issues: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) GetIssue(ctx context.Context, owner, repo string, number int) (*Issue, error) {
+	var out Issue
+	if _, err := c.api.Do(ctx, http.MethodGet, issuePath(owner, repo, number), nil, &out); err != nil {
+		return nil, fmt.Errorf("get issue #%d: %w", number, err)
+	}
+	return &out, nil
+}
+
+// IssueUpdate holds the fields to change; nil fields are left alone.
+type IssueUpdate struct {
+	Title       *string `json:"title,omitempty"`
+	Body        *string `json:"body,omitempty"`
+	State       *string `json:"state,omitempty"`        // open|closed
+	StateReason *string `json:"state_reason,omitempty"` // completed|not_planned|reopened
+}
+
+func (c *Client) UpdateIssue(ctx context.Context, owner, repo string, number int, upd IssueUpdate) error {
+	if _, err := c.api.Do(ctx, http.MethodPatch, issuePath(owner, repo, number), upd, nil); err != nil {
+		return fmt.Errorf("update issue #%d: %w", number, err)
+	}
+	return nil
+}
+
+// CreateComment comments on an issue or pull request and returns the
+// comment URL.
+func (c *Client) CreateComment(ctx context.Context, owner, repo string, number int, body string) (string, error) {
+	var out struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if _, err := c.api.Do(ctx, http.MethodPost, issuePath(owner, repo, number)+"/comments", map[string]string{"body": body}, &out); err != nil {
+		return "", fmt.Errorf("comment on #%d: %w", number, err)
+	}
+	return out.HTMLURL, nil
+}
+
+func (c *Client) AddLabels(ctx context.Context, owner, repo string, number int, labels []string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	if _, err := c.api.Do(ctx, http.MethodPost, issuePath(owner, repo, number)+"/labels", map[string][]string{"labels": labels}, nil); err != nil {
+		return fmt.Errorf("add labels to #%d: %w", number, err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorankrgovic/dai/internal/rest"
+)
+
+type PullRequest struct {
+	Number  int     `json:"number"`
+	Title   string  `json:"title"`
+	State   string  `json:"state"`
+	HTMLURL string  `json:"html_url"`
+	Head    PullRef `json:"head"`
+	Base    PullRef `json:"base"`
+}
+
+type PullRef struct {
+	Ref  string `json:"ref"`
+	SHA  string `json:"sha"`
+	Repo *struct {
+		FullName string `json:"full_name"`
+	} `json:"repo"`
+}
+
+type User struct {
+	Login string `json:"login"`
+}
+
+// ReviewComment is a comment on a line of a pull request diff.
+type ReviewComment struct {
+	ID          int64  `json:"id"`
+	Body        string `json:"body"`
+	Path        string `json:"path"`
+	Line        int    `json:"line"`
+	CommitID    string `json:"commit_id"`
+	InReplyToID int64  `json:"in_reply_to_id"`
+	User        User   `json:"user"`
+	HTMLURL     string `json:"html_url"`
+}
+
+type Review struct {
+	ID      int64  `json:"id"`
+	Body    string `json:"body"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+	User    User   `json:"user"`
+}
+
+// DraftComment is an inline comment submitted as part of a review. Line
+// (and StartLine for multi-line comments) refer to the new side of the diff.
+type DraftComment struct {
+	Path      string `json:"path"`
+	Body      string `json:"body"`
+	Line      int    `json:"line"`
+	Side      string `json:"side,omitempty"`
+	StartLine int    `json:"start_line,omitempty"`
+	StartSide string `json:"start_side,omitempty"`
+}
+
+type reviewReq struct {
+	CommitID string         `json:"commit_id,omitempty"`
+	Body     string         `json:"body,omitempty"`
+	Event    string         `json:"event"`
+	Comments []DraftComment `json:"comments,omitempty"`
+}
+
+func pullPath(owner, repo string, number int) string {
+	return repoPath(owner, repo) + "/pulls/" + strconv.Itoa(number)
+}
+
+func (c *Client) GetPullRequest(ctx context.Context, owner, repo string, number int) (*PullRequest, error) {
+	var pr PullRequest
+	if _, err := c.api.Do(ctx, http.MethodGet, pullPath(owner, repo, number), nil, &pr); err != nil {
+		return nil, fmt.Errorf("get pull request #%d: %w", number, err)
+	}
+	return &pr, nil
+}
+
+func (c *Client) ListReviewComments(ctx context.Context, owner, repo string, number int) ([]ReviewComment, error) {
+	var out []ReviewComment
+	err := rest.GetPages(ctx, c.api, pullPath(owner, repo, number)+"/comments?per_page=100", func(page []ReviewComment) error {
+		out = append(out, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list review comments: %w", err)
+	}
+	return out, nil
+}
+
+func (c *Client) ListReviews(ctx context.Context, owner, repo string, number int) ([]Review, error) {
+	var out []Review
+	err := rest.GetPages(ctx, c.api, pullPath(owner, repo, number)+"/reviews?per_page=100", func(page []Review) error {
+		out = append(out, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list reviews: %w", err)
+	}
+	return out, nil
+}
+
+// CreateReview submits a COMMENT review on commitID with inline comments.
+func (c *Client) CreateReview(ctx context.Context, owner, repo string, number int, commitID, body string, comments []DraftComment) (*Review, error) {
+	req := reviewReq{CommitID: commitID, Body: body, Event: "COMMENT", Comments: comments}
+	var out Review
+	if _, err := c.api.Do(ctx, http.MethodPost, pullPath(owner, repo, number)+"/reviews", req, &out); err != nil {
+		return nil, fmt.Errorf("create review: %w", err)
+	}
+	return &out, nil
+}
+
+// UpdateReview replaces the summary body of a submitted review.
+func (c *Client) UpdateReview(ctx context.Context, owner, repo string, number int, id int64, body string) error {
+	path := pullPath(owner, repo, number) + "/reviews/" + strconv.FormatInt(id, 10)
+	if _, err := c.api.Do(ctx, http.MethodPut, path, map[string]string{"body": body}, nil); err != nil {
+		return fmt.Errorf("update review: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) UpdateReviewComment(ctx context.Context, owner, repo string, id int64, body string) error {
+	path := repoPath(owner, repo) + "/pulls/comments/" + strconv.FormatInt(id, 10)
+	if _, err := c.api.Do(ctx, http.MethodPatch, path, map[string]string{"body": body}, nil); err != nil {
+		return fmt.Errorf("update review comment: %w", err)
+	}
+	return nil
+}
+
+// PullFile is an entry of the pull request files API. Patch is empty for
+// binary files and for diffs GitHub considers too large to return.
+type PullFile struct {
+	Filename         string `json:"filename"`
+	PreviousFilename string `json:"previous_filename"`
+	Status           string `json:"status"` // added|removed|modified|renamed|copied|changed|unchanged
+	Additions        int    `json:"additions"`
+	Deletions        int    `json:"deletions"`
+	Changes          int    `json:"changes"`
+	Patch            string `json:"patch"`
+}
+
+// MaxPullFiles is the most files the API lists for one pull request.
+const MaxPullFiles = 3000
+
+// ListPullRequestFiles pages through the changed files, calling fn once per
+// file.
+func (c *Client) ListPullRequestFiles(ctx context.Context, owner, repo string, number int, fn func(PullFile) error) error {
+	err := rest.GetPages(ctx, c.api, pullPath(owner, repo, number)+"/files?per_page=100", func(page []PullFile) error {
+		for _, f := range page {
+			if err := fn(f); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("list pull request files: %w", err)
+	}
+	return nil
+}
+
+// CreatePullRequest opens a pull request from branch req.Head into
+// req.Base.
+func (c *Client) CreatePullRequest(ctx context.Context, owner, repo string, req rest.NewPull) (*PullRequest, error) {
+	var pr PullRequest
+	if _, err := c.api.Do(ctx, http.MethodPost, repoPath(owner, repo)+"/pulls", req, &pr); err != nil {
+		return nil, fmt.Errorf("create pull request: %w", err)
+	}
+	return &pr, nil
+}
@@ -0,0 +1,40 @@
+package gh
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TokenInfo describes the token a client authenticates with.
+type TokenInfo struct {
+	Login   string
+	Scopes  []string  // classic tokens only; fine-grained tokens report none
+	Expires time.Time // zero when the token does not expire
+}
+
+// WhoAmI returns the user behind the token, with the scopes and expiry
+// GitHub reports in the response headers.
+func (c *Client) WhoAmI(ctx context.Context) (*TokenInfo, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	resp, err := c.api.Do(ctx, http.MethodGet, "/user", nil, &user)
+	if err != nil {
+		return nil, err
+	}
+	info := &TokenInfo{Login: user.Login}
+	for _, s := range strings.Split(resp.Header.Get("X-OAuth-Scopes"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			info.Scopes = append(info.Scopes, s)
+		}
+	}
+	// e.g. "2026-01-31 12:00:00 UTC"
+	if exp := resp.Header.Get("GitHub-Authentication-Token-Expiration"); exp != "" {
+		if t, err := time.Parse("2006-01-02 15:04:05 MST", exp); err == nil {
+			info.Expires = t
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,54 @@
+// Package gitea is a small client for the REST API (v1) shared by Gitea and
+// Forgejo.
+package gitea
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorankrgovic/dai/internal/rest"
+)
+
+// Typed errors, matched with errors.Is against an *APIError.
+var (
+	ErrUnauthorized = rest.Status(http.StatusUnauthorized, "gitea: unauthorized (check the token)")
+	ErrForbidden    = rest.Status(http.StatusForbidden, "gitea: forbidden (missing token scope or permission)")
+	ErrNotFound     = rest.Status(http.StatusNotFound, "gitea: not found")
+	ErrConflict     = rest.Status(http.StatusConflict, "gitea: already exists")
+	ErrValidation   = rest.Status(http.StatusUnprocessableEntity, "gitea: validation failed")
+)
+
+// APIError is a non-2xx response from the API.
+type APIError = rest.APIError
+
+// Client talks to a Gitea or Forgejo instance with an access token.
+type Client struct {
+	api *rest.Client
+}
+
+// NewClient creates a client for an API root such as
+// https://codeberg.org/api/v1.
+func NewClient(base, token string) *Client {
+	token = strings.TrimSpace(token)
+	return &Client{api: rest.New("gitea", base, func(_ context.Context, h http.Header) error {
+		if token != "" {
+			h.Set("Authorization", "token "+token)
+		}
+		return nil
+	})}
+}
+
+// APIBase returns the API root of a Gitea/Forgejo host.
+func APIBase(host string) string {
+	h := strings.ToLower(strings.TrimSpace(host))
+	if h == "" {
+		h = "codeberg.org"
+	}
+	return "https://" + h + "/api/v1"
+}
+
+func repoPath(owner, repo string) string {
+	return "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+}
@@ -0,0 +1,175 @@
+package gitea
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gorankrgovic/dai/internal/rest"
+)
+
+type Issue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	State   string `json:"state"` // open|closed
+	HTMLURL string `json:"html_url"`
+}
+
+type Label struct {
+	ID    int64  `json:"id,omitempty"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type issueReq struct {
+	Title     string   `json:"title"`
+	Body      string   `json:"body,omitempty"`
+	Labels    []int64  `json:"labels,omitempty"`
+	Assignees []string `json:"assignees,omitempty"`
+}
+
+func issuePath(owner, repo string, number int) string {
+	return repoPath(owner, repo) + "/issues/" + strconv.Itoa(number)
+}
+
+// CreateIssue opens an issue. Labels are IDs (see EnsureLabels); assignees the
+// server refuses make it retry once without them.
+func (c *Client) CreateIssue(ctx context.Context, owner, repo, title, body string, labels []int64, assignees []string) (*Issue, error) {
+	req := issueReq{Title: title, Body: body, Labels: labels, Assignees: assignees}
+	var out Issue
+	_, err := c.api.Do(ctx, http.MethodPost, repoPath(owner, repo)+"/issues", req, &out)
+	if err != nil && len(assignees) > 0 && (errors.Is(err, ErrValidation) || errors.Is(err, ErrNotFound)) {
+		req.Assignees = nil
+		_, err = c.api.Do(ctx, http.MethodPost, repoPath(owner, repo)+"/issues", req, &out)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create issue: %w", err)
+	}
+	return &out, nil
+}
+
+// ListIssues pages through issues (not pull requests) in state
+// (open|closed|all), newest first. fn returns false to stop early.
+func (c *Client) ListIssues(ctx context.Context, owner, repo, state string, fn func(Issue) bool) error {
+	q := url.Values{"state": {state}, "type": {"issues"}, "limit": {"50"}}
+	err := rest.GetPages(ctx, c.api, repoPath(owner, repo)+"/issues?"+q.Encode(), func(page []Issue) error {
+		for _, is := range page {
+			if !fn(is) {
+				return rest.ErrStopPaging
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("list issues: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) GetIssue(ctx context.Context, owner, repo string, number int) (*Issue, error) {
+	var out Issue
+	if _, err := c.api.Do(ctx, http.MethodGet, issuePath(owner, repo, number), nil, &out); err != nil {
+		return nil, fmt.Errorf("get issue #%d: %w", number, err)
+	}
+	return &out, nil
+}
+
+// IssueUpdate holds the fields to change; nil fields are left alone.
+type IssueUpdate struct {
+	Body  *string `json:"body,omitempty"`
+	State *string `json:"state,omitempty"`
+}
+
+func (c *Client) UpdateIssue(ctx context.Context, owner, repo string, number int, upd IssueUpdate) error {
+	if _, err := c.api.Do(ctx, http.MethodPatch, issuePath(owner, repo, number), upd, nil); err != nil {
+		return fmt.Errorf("update issue #%d: %w", number, err)
+	}
+	return nil
+}
+
+type Comment struct {
+	ID      int64  `json:"id"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+}
+
+// CreateComment comments on an issue or pull request.
+func (c *Client) CreateComment(ctx context.Context, owner, repo string, number int, body string) (*Comment, error) {
+	var out Comment
+	if _, err := c.api.Do(ctx, http.MethodPost, issuePath(owner, repo, number)+"/comments", map[string]string{"body": body}, &out); err != nil {
+		return nil, fmt.Errorf("comment on #%d: %w", number, err)
+	}
+	return &out, nil
+}
+
+// EditComment replaces the body of an issue comment or a review comment.
+func (c *Client) EditComment(ctx context.Context, owner, repo string, id int64, body string) error {
+	path := repoPath(owner, repo) + "/issues/comments/" + strconv.FormatInt(id, 10)
+	if _, err := c.api.Do(ctx, http.MethodPatch, path, map[string]string{"body": body}, nil); err != nil {
+		return fmt.Errorf("edit comment: %w", err)
+	}
+	return nil
+}
+
+// AddLabels adds labels (IDs) to an issue.
+func (c *Client) AddLabels(ctx context.Context, owner, repo string, number int, labels []int64) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	if _, err := c.api.Do(ctx, http.MethodPost, issuePath(owner, repo, number)+"/labels", map[string][]int64{"labels": labels}, nil); err != nil {
+		return fmt.Errorf("add labels: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) ListLabels(ctx context.Context, owner, repo string) ([]Label, error) {
+	var out []Label
+	err := rest.GetPages(ctx, c.api, repoPath(owner, repo)+"/labels?limit=50", func(page []Label) error {
+		out = append(out, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list labels: %w", err)
+	}
+	return out, nil
+}
+
+// EnsureLabels creates the labels the repository does not have yet and
+// returns the IDs of all of them, in order. color returns hex without '#'.
+func (c *Client) EnsureLabels(ctx context.Context, owner, repo string, names []string, color func(string) string) ([]int64, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	existing, err := c.ListLabels(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]int64, len(existing))
+	for _, l := range existing {
+		ids[labelKey(l.Name)] = l.ID
+	}
+	out := make([]int64, 0, len(names))
+	for _, name := range names {
+		id, ok := ids[labelKey(name)]
+		if !ok {
+			var l Label
+			req := Label{Name: name, Color: "#" + color(name)}
+			if _, err := c.api.Do(ctx, http.MethodPost, repoPath(owner, repo)+"/labels", req, &l); err != nil {
+				return nil, fmt.Errorf("create label: %w", err)
+			}
+			id = l.ID
+			ids[labelKey(name)] = id
+		}
+		out = append(out, id)
+	}
+	return out, nil
+}
+
+func labelKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
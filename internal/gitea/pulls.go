@@ -0,0 +1,115 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorankrgovic/dai/internal/rest"
+)
+
+type PullRequest struct {
+	Number    int     `json:"number"`
+	Title     string  `json:"title"`
+	HTMLURL   string  `json:"html_url"`
+	Head      PullRef `json:"head"`
+	Base      PullRef `json:"base"`
+	MergeBase string  `json:"merge_base"`
+}
+
+type PullRef struct {
+	Ref string `json:"ref"`
+	SHA string `json:"sha"`
+}
+
+type Review struct {
+	ID       int64  `json:"id"`
+	Body     string `json:"body"`
+	State    string `json:"state"`
+	HTMLURL  string `json:"html_url"`
+	Comments int    `json:"comments_count"`
+}
+
+// ReviewComment is a comment on a line of the diff. Position is the line
+// in the new file (0 when the line is gone); Resolver is set once the
+// conversation was resolved.
+type ReviewComment struct {
+	ID               int64  `json:"id"`
+	Body             string `json:"body"`
+	Path             string `json:"path"`
+	Position         int    `json:"position"`
+	OriginalPosition int    `json:"original_position"`
+	HTMLURL          string `json:"html_url"`
+	Resolver         *struct {
+		Login string `json:"login"`
+	} `json:"resolver"`
+}
+
+// DraftComment is an inline comment submitted with a review. NewPosition
+// is a line of the new file.
+type DraftComment struct {
+	Path        string `json:"path"`
+	Body        string `json:"body"`
+	NewPosition int    `json:"new_position"`
+}
+
+type reviewReq struct {
+	CommitID string         `json:"commit_id,omitempty"`
+	Body     string         `json:"body,omitempty"`
+	Event    string         `json:"event"`
+	Comments []DraftComment `json:"comments,omitempty"`
+}
+
+func pullPath(owner, repo string, number int) string {
+	return repoPath(owner, repo) + "/pulls/" + strconv.Itoa(number)
+}
+
+func (c *Client) GetPullRequest(ctx context.Context, owner, repo string, number int) (*PullRequest, error) {
+	var pr PullRequest
+	if _, err := c.api.Do(ctx, http.MethodGet, pullPath(owner, repo, number), nil, &pr); err != nil {
+		return nil, fmt.Errorf("get pull request #%d: %w", number, err)
+	}
+	return &pr, nil
+}
+
+func (c *Client) ListReviews(ctx context.Context, owner, repo string, number int) ([]Review, error) {
+	var out []Review
+	err := rest.GetPages(ctx, c.api, pullPath(owner, repo, number)+"/reviews?limit=50", func(page []Review) error {
+		out = append(out, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list reviews: %w", err)
+	}
+	return out, nil
+}
+
+func (c *Client) ListReviewComments(ctx context.Context, owner, repo string, number int, reviewID int64) ([]ReviewComment, error) {
+	var out []ReviewComment
+	path := pullPath(owner, repo, number) + "/reviews/" + strconv.FormatInt(reviewID, 10) + "/comments"
+	if _, err := c.api.Do(ctx, http.MethodGet, path, nil, &out); err != nil {
+		return nil, fmt.Errorf("list review comments: %w", err)
+	}
+	return out, nil
+}
+
+// CreateReview submits a COMMENT review on commitID with inline comments.
+func (c *Client) CreateReview(ctx context.Context, owner, repo string, number int, commitID, body string, comments []DraftComment) (*Review, error) {
+	req := reviewReq{CommitID: commitID, Body: body, Event: "COMMENT", Comments: comments}
+	var out Review
+	if _, err := c.api.Do(ctx, http.MethodPost, pullPath(owner, repo, number)+"/reviews", req, &out); err != nil {
+		return nil, fmt.Errorf("create review: %w", err)
+	}
+	return &out, nil
+}
+
+// CreatePullRequest opens a pull request from branch req.Head into
+// req.Base.
+func (c *Client) CreatePullRequest(ctx context.Context, owner, repo string, req rest.NewPull) (*PullRequest, error) {
+	var pr PullRequest
+	if _, err := c.api.Do(ctx, http.MethodPost, repoPath(owner, repo)+"/pulls", req, &pr); err != nil {
+		return nil, fmt.Errorf("create pull request: %w", err)
+	}
+	return &pr, nil
+}
@@ -0,0 +1,17 @@
+package gitea
+
+import (
+	"context"
+	"net/http"
+)
+
+// WhoAmI returns the login of the user behind the token.
+func (c *Client) WhoAmI(ctx context.Context) (string, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	if _, err := c.api.Do(ctx, http.MethodGet, "/user", nil, &user); err != nil {
+		return "", err
+	}
+	return user.Login, nil
+}
@@ -0,0 +1,78 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorankrgovic/dai/internal/rest"
+)
+
+// Typed errors, matched with errors.Is against an *APIError.
+var (
+	ErrUnauthorized = rest.Status(http.StatusUnauthorized, "gitlab: unauthorized (check the token)")
+	ErrForbidden    = rest.Status(http.StatusForbidden, "gitlab: forbidden (missing scope or role)")
+	ErrNotFound     = rest.Status(http.StatusNotFound, "gitlab: not found")
+	ErrConflict     = rest.Status(http.StatusConflict, "gitlab: already exists")
+	ErrBadRequest   = rest.Status(http.StatusBadRequest, "gitlab: bad request")
+)
+
+// APIError is a non-2xx response from the API.
+type APIError = rest.APIError
+
+// Client talks to the REST API (v4) of gitlab.com or a self-managed
+// instance. Personal, project and group access tokens all work.
+type Client struct {
+	api *rest.Client
+}
+
+// NewClient creates a client for an API root such as
+// https://gitlab.com/api/v4.
+func NewClient(base, token string) *Client {
+	token = strings.TrimSpace(token)
+	api := rest.New("gitlab", base, func(_ context.Context, h http.Header) error {
+		if token != "" {
+			h.Set("PRIVATE-TOKEN", token)
+		}
+		return nil
+	})
+	api.ErrorMessage = errorMessage
+	return &Client{api: api}
+}
+
+// APIBase returns the API root of a GitLab host.
+func APIBase(host string) string {
+	h := strings.ToLower(strings.TrimSpace(host))
+	if h == "" {
+		h = "gitlab.com"
+	}
+	return "https://" + h + "/api/v4"
+}
+
+// projectPath returns the URL-encoded project ID for namespace/repo.
+func projectPath(namespace, repo string) string {
+	return "/projects/" + url.PathEscape(namespace+"/"+repo)
+}
+
+// errorMessage flattens GitLab's {"message": ...} or {"error": ...} bodies;
+// message may be a string, a list or a map of field errors.
+func errorMessage(raw []byte) string {
+	var body struct {
+		Message any    `json:"message"`
+		Error   string `json:"error"`
+	}
+	if json.Unmarshal(raw, &body) != nil {
+		return ""
+	}
+	switch m := body.Message.(type) {
+	case string:
+		return m
+	case nil:
+		return body.Error
+	default:
+		b, _ := json.Marshal(m)
+		return string(b)
+	}
+}
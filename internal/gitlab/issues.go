@@ -0,0 +1,143 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gorankrgovic/dai/internal/rest"
+)
+
+type Issue struct {
+	IID         int    `json:"iid"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	State       string `json:"state"` // opened|closed
+	WebURL      string `json:"web_url"`
+}
+
+type label struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type issueReq struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Labels      string `json:"labels,omitempty"`
+	AssigneeIDs []int  `json:"assignee_ids,omitempty"`
+}
+
+func issuePath(namespace, repo string, iid int) string {
+	return projectPath(namespace, repo) + "/issues/" + strconv.Itoa(iid)
+}
+
+// CreateIssue opens an issue; assignees are usernames, and ones that do
+// not resolve to a user are dropped.
+func (c *Client) CreateIssue(ctx context.Context, namespace, repo, title, description string, labels, assignees []string) (*Issue, error) {
+	req := issueReq{Title: title, Description: description, Labels: strings.Join(labels, ",")}
+	for _, u := range assignees {
+		if id, err := c.userID(ctx, u); err == nil && id > 0 {
+			req.AssigneeIDs = append(req.AssigneeIDs, id)
+		}
+	}
+	var out Issue
+	if _, err := c.api.Do(ctx, http.MethodPost, projectPath(namespace, repo)+"/issues", req, &out); err != nil {
+		return nil, fmt.Errorf("create issue: %w", err)
+	}
+	return &out, nil
+}
+
+func (c *Client) userID(ctx context.Context, username string) (int, error) {
+	var users []struct {
+		ID int `json:"id"`
+	}
+	if _, err := c.api.Do(ctx, http.MethodGet, "/users?username="+url.QueryEscape(username), nil, &users); err != nil {
+		return 0, err
+	}
+	if len(users) == 0 {
+		return 0, nil
+	}
+	return users[0].ID, nil
+}
+
+// ListIssues pages through issues in state (opened|closed|all), newest
+// first. fn returns false to stop early.
+func (c *Client) ListIssues(ctx context.Context, namespace, repo, state string, fn func(Issue) bool) error {
+	q := url.Values{"state": {state}, "per_page": {"100"}, "order_by": {"created_at"}, "sort": {"desc"}}
+	err := rest.GetPages(ctx, c.api, projectPath(namespace, repo)+"/issues?"+q.Encode(), func(page []Issue) error {
+		for _, is := range page {
+			if !fn(is) {
+				return rest.ErrStopPaging
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("list issues: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) GetIssue(ctx context.Context, namespace, repo string, iid int) (*Issue, error) {
+	var out Issue
+	if _, err := c.api.Do(ctx, http.MethodGet, issuePath(namespace, repo, iid), nil, &out); err != nil {
+		return nil, fmt.Errorf("get issue #%d: %w", iid, err)
+	}
+	return &out, nil
+}
+
+// IssueUpdate holds the fields to change; nil fields are left alone.
+type IssueUpdate struct {
+	Description *string `json:"description,omitempty"`
+	StateEvent  *string `json:"state_event,omitempty"` // close|reopen
+	AddLabels   string  `json:"add_labels,omitempty"`
+}
+
+func (c *Client) UpdateIssue(ctx context.Context, namespace, repo string, iid int, upd IssueUpdate) error {
+	if _, err := c.api.Do(ctx, http.MethodPut, issuePath(namespace, repo, iid), upd, nil); err != nil {
+		return fmt.Errorf("update issue #%d: %w", iid, err)
+	}
+	return nil
+}
+
+// CreateIssueNote comments on an issue and returns the note ID.
+func (c *Client) CreateIssueNote(ctx context.Context, namespace, repo string, iid int, body string) (int, error) {
+	var out struct {
+		ID int `json:"id"`
+	}
+	if _, err := c.api.Do(ctx, http.MethodPost, issuePath(namespace, repo, iid)+"/notes", map[string]string{"body": body}, &out); err != nil {
+		return 0, fmt.Errorf("comment on #%d: %w", iid, err)
+	}
+	return out.ID, nil
+}
+
+func (c *Client) EnsureLabels(ctx context.Context, namespace, repo string, labels []string, color func(string) string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	have := map[string]bool{}
+	err := rest.GetPages(ctx, c.api, projectPath(namespace, repo)+"/labels?per_page=100&include_ancestor_groups=true", func(page []label) error {
+		for _, l := range page {
+			have[strings.ToLower(strings.TrimSpace(l.Name))] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("list labels: %w", err)
+	}
+	for _, name := range labels {
+		if have[strings.ToLower(strings.TrimSpace(name))] {
+			continue
+		}
+		_, err := c.api.Do(ctx, http.MethodPost, projectPath(namespace, repo)+"/labels", label{Name: name, Color: "#" + color(name)}, nil)
+		if err != nil && !errors.Is(err, ErrConflict) {
+			return fmt.Errorf("create label: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorankrgovic/dai/internal/rest"
+)
+
+type MergeRequest struct {
+	IID          int    `json:"iid"`
+	Title        string `json:"title"`
+	WebURL       string `json:"web_url"`
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+	SHA          string `json:"sha"`
+	DiffRefs     struct {
+		BaseSHA  string `json:"base_sha"`
+		HeadSHA  string `json:"head_sha"`
+		StartSHA string `json:"start_sha"`
+	} `json:"diff_refs"`
+}
+
+type Note struct {
+	ID       int    `json:"id"`
+	Type     string `json:"type"` // DiffNote, DiscussionNote or "" for plain notes
+	Body     string `json:"body"`
+	System   bool   `json:"system"`
+	Resolved bool   `json:"resolved"`
+	Position *struct {
+		NewLine int `json:"new_line"`
+	} `json:"position"`
+}
+
+type Discussion struct {
+	ID    string `json:"id"`
+	Notes []Note `json:"notes"`
+}
+
+// Position anchors a discussion on a line of the new side of the diff.
+type Position struct {
+	PositionType string `json:"position_type"` // text
+	BaseSHA      string `json:"base_sha"`
+	StartSHA     string `json:"start_sha"`
+	HeadSHA      string `json:"head_sha"`
+	OldPath      string `json:"old_path"`
+	NewPath      string `json:"new_path"`
+	NewLine      int    `json:"new_line"`
+}
+
+func mrPath(namespace, repo string, iid int) string {
+	return projectPath(namespace, repo) + "/merge_requests/" + strconv.Itoa(iid)
+}
+
+func (c *Client) GetMergeRequest(ctx context.Context, namespace, repo string, iid int) (*MergeRequest, error) {
+	var mr MergeRequest
+	if _, err := c.api.Do(ctx, http.MethodGet, mrPath(namespace, repo, iid), nil, &mr); err != nil {
+		return nil, fmt.Errorf("get merge request !%d: %w", iid, err)
+	}
+	return &mr, nil
+}
+
+func (c *Client) ListDiscussions(ctx context.Context, namespace, repo string, iid int) ([]Discussion, error) {
+	var out []Discussion
+	err := rest.GetPages(ctx, c.api, mrPath(namespace, repo, iid)+"/discussions?per_page=100", func(page []Discussion) error {
+		out = append(out, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list discussions: %w", err)
+	}
+	return out, nil
+}
+
+// CreateDiscussion starts a thread, on a diff line when pos is non-nil.
+func (c *Client) CreateDiscussion(ctx context.Context, namespace, repo string, iid int, body string, pos *Position) (*Discussion, error) {
+	req := struct {
+		Body     string    `json:"body"`
+		Position *Position `json:"position,omitempty"`
+	}{body, pos}
+	var out Discussion
+	if _, err := c.api.Do(ctx, http.MethodPost, mrPath(namespace, repo, iid)+"/discussions", req, &out); err != nil {
+		return nil, fmt.Errorf("create discussion: %w", err)
+	}
+	return &out, nil
+}
+
+func (c *Client) UpdateDiscussionNote(ctx context.Context, namespace, repo string, iid int, discussionID string, noteID int, body string) error {
+	path := mrPath(namespace, repo, iid) + "/discussions/" + discussionID + "/notes/" + strconv.Itoa(noteID)
+	if _, err := c.api.Do(ctx, http.MethodPut, path, map[string]string{"body": body}, nil); err != nil {
+		return fmt.Errorf("update note: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) ResolveDiscussion(ctx context.Context, namespace, repo string, iid int, discussionID string, resolved bool) error {
+	path := mrPath(namespace, repo, iid) + "/discussions/" + discussionID + "?resolved=" + strconv.FormatBool(resolved)
+	if _, err := c.api.Do(ctx, http.MethodPut, path, nil, nil); err != nil {
+		return fmt.Errorf("resolve discussion: %w", err)
+	}
+	return nil
+}
+
+type mrReq struct {
+	Title              string `json:"title"`
+	Description        string `json:"description,omitempty"`
+	SourceBranch       string `json:"source_branch"`
+	TargetBranch       string `json:"target_branch"`
+	RemoveSourceBranch bool   `json:"remove_source_branch,omitempty"`
+}
+
+// CreateMergeRequest opens a merge request from branch source into target;
+// the source branch is removed once it is merged.
+func (c *Client) CreateMergeRequest(ctx context.Context, namespace, repo, title, description, source, target string) (*MergeRequest, error) {
+	var mr MergeRequest
+	req := mrReq{Title: title, Description: description, SourceBranch: source, TargetBranch: target, RemoveSourceBranch: true}
+	if _, err := c.api.Do(ctx, http.MethodPost, projectPath(namespace, repo)+"/merge_requests", req, &mr); err != nil {
+		return nil, fmt.Errorf("create merge request: %w", err)
+	}
+	return &mr, nil
+}
@@ -0,0 +1,42 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// TokenInfo describes the token a client authenticates with.
+type TokenInfo struct {
+	Username string
+	Name     string // the token's name
+	Scopes   []string
+	Expires  time.Time // zero when the token does not expire
+}
+
+// WhoAmI returns the user behind the token and, where the server supports
+// it (GitLab 15.5+), the token's name, scopes and expiry.
+func (c *Client) WhoAmI(ctx context.Context) (*TokenInfo, error) {
+	var user struct {
+		Username string `json:"username"`
+	}
+	if _, err := c.api.Do(ctx, http.MethodGet, "/user", nil, &user); err != nil {
+		return nil, err
+	}
+	info := &TokenInfo{Username: user.Username}
+	var tok struct {
+		Name      string   `json:"name"`
+		Scopes    []string `json:"scopes"`
+		ExpiresAt string   `json:"expires_at"` // YYYY-MM-DD
+	}
+	_, err := c.api.Do(ctx, http.MethodGet, "/personal_access_tokens/self", nil, &tok)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	info.Name, info.Scopes = tok.Name, tok.Scopes
+	if t, err := time.Parse("2006-01-02", tok.ExpiresAt); err == nil {
+		info.Expires = t
+	}
+	return info, nil
+}
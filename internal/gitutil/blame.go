@@ -0,0 +1,76 @@
+package gitutil
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// BlameLine is one line of `git blame --line-porcelain` output.
+type BlameLine struct {
+	Commit      string
+	Line        int // line number in the blamed revision
+	AuthorName  string
+	AuthorEmail string
+}
+
+// Blame attributes lines start..end of path as of commit.
+func Blame(ctx context.Context, dir, commit, path string, start, end int) ([]BlameLine, error) {
+	if start <= 0 || end < start {
+		return nil, fmt.Errorf("invalid blame range %d-%d", start, end)
+	}
+	args := []string{"blame", "--line-porcelain", "-w", "-L", fmt.Sprintf("%d,%d", start, end), commit, "--", path}
+
+	var lines []BlameLine
+	err := streamGit(ctx, dir, args, func(r io.Reader) error {
+		lr := newLineReader(r)
+		var cur *BlameLine
+		for {
+			l, err := lr.next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			switch {
+			case strings.HasPrefix(l, "\t"):
+				// the content line closes an entry
+				if cur != nil {
+					lines = append(lines, *cur)
+					cur = nil
+				}
+			case cur == nil:
+				// "<sha> <orig-line> <final-line> [<count>]"
+				f := strings.Fields(l)
+				if len(f) >= 3 {
+					cur = &BlameLine{Commit: f[0], Line: atoiDefault(f[2])}
+				}
+			case strings.HasPrefix(l, "author "):
+				cur.AuthorName = strings.TrimPrefix(l, "author ")
+			case strings.HasPrefix(l, "author-mail "):
+				mail := strings.TrimPrefix(l, "author-mail ")
+				cur.AuthorEmail = strings.TrimSuffix(strings.TrimPrefix(mail, "<"), ">")
+			}
+		}
+	})
+	return lines, err
+}
+
+// TopAuthor returns the blame entry of the author owning most of the
+// given lines.
+func TopAuthor(lines []BlameLine) BlameLine {
+	counts := map[string]int{}
+	var top BlameLine
+	best := 0
+	for _, l := range lines {
+		key := strings.ToLower(l.AuthorEmail)
+		counts[key]++
+		if counts[key] > best {
+			best = counts[key]
+			top = l
+		}
+	}
+	return top
+}
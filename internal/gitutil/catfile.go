@@ -0,0 +1,176 @@
+package gitutil
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrObjectNotFound is returned by CatFile when <rev>:<path> does not exist.
+var ErrObjectNotFound = errors.New("git object not found")
+
+// binarySniffBytes mirrors git's own heuristic: a NUL byte in the first
+// 8000 bytes marks a file as binary.
+const binarySniffBytes = 8000
+
+// Blob is a file read through a CatFile session.
+type Blob struct {
+	OID       string
+	Size      int64  // full size in the object database
+	Content   []byte // at most the session's byte limit
+	Truncated bool
+	Binary    bool
+}
+
+// CatFile is a long-lived `git cat-file --batch` process. It is safe for
+// concurrent use; requests are serialized over the single pipe.
+type CatFile struct {
+	maxBytes int64
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	closed bool
+	done   chan struct{}
+	err    error // sticky protocol error
+}
+
+// NewCatFile starts a session in dir. Blobs larger than maxBytes are
+// truncated (0 means no limit). The process is killed when ctx ends.
+func NewCatFile(ctx context.Context, dir string, maxBytes int64) (*CatFile, error) {
+	cmd := exec.CommandContext(ctx, "git", "cat-file", "--batch")
+	cmd.Dir = dir
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("git cat-file --batch: %w", err)
+	}
+	c := &CatFile{
+		maxBytes: maxBytes,
+		cmd:      cmd,
+		stdin:    stdin,
+		stdout:   bufio.NewReaderSize(stdout, 64*1024),
+		done:     make(chan struct{}),
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Close()
+		case <-c.done:
+		}
+	}()
+	return c, nil
+}
+
+// Read returns the blob at <rev>:<path>.
+func (c *CatFile) Read(rev, path string) (*Blob, error) {
+	if strings.ContainsAny(path, "\n") || strings.ContainsAny(rev, "\n") {
+		return nil, fmt.Errorf("cat-file: newline in object name %q:%q", rev, path)
+	}
+	return c.ReadObject(rev + ":" + path)
+}
+
+// ReadObject returns the blob named by any git object spec.
+func (c *CatFile) ReadObject(spec string) (*Blob, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, errors.New("cat-file: session closed")
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	if _, err := io.WriteString(c.stdin, spec+"\n"); err != nil {
+		return nil, c.fail(err)
+	}
+	header, err := c.stdout.ReadString('\n')
+	if err != nil {
+		return nil, c.fail(err)
+	}
+	header = strings.TrimSuffix(header, "\n")
+	if strings.HasSuffix(header, " missing") || strings.HasSuffix(header, " ambiguous") {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, spec)
+	}
+
+	// "<oid> <type> <size>"
+	f := strings.Fields(header)
+	if len(f) != 3 {
+		return nil, c.fail(fmt.Errorf("unexpected header %q", header))
+	}
+	size, err := strconv.ParseInt(f[2], 10, 64)
+	if err != nil {
+		return nil, c.fail(fmt.Errorf("unexpected header %q", header))
+	}
+
+	keep := size
+	if c.maxBytes > 0 && keep > c.maxBytes {
+		keep = c.maxBytes
+	}
+	content := make([]byte, keep)
+	if _, err := io.ReadFull(c.stdout, content); err != nil {
+		return nil, c.fail(err)
+	}
+	// discard the rest of the object plus the trailing LF
+	if _, err := io.CopyN(io.Discard, c.stdout, size-keep+1); err != nil {
+		return nil, c.fail(err)
+	}
+
+	if f[1] != "blob" {
+		return nil, fmt.Errorf("cat-file: %s is a %s, not a blob", spec, f[1])
+	}
+	sniff := content
+	if len(sniff) > binarySniffBytes {
+		sniff = sniff[:binarySniffBytes]
+	}
+	return &Blob{
+		OID:       f[0],
+		Size:      size,
+		Content:   content,
+		Truncated: keep < size,
+		Binary:    bytes.IndexByte(sniff, 0) >= 0,
+	}, nil
+}
+
+// fail records a protocol error; the stream is out of sync afterwards.
+func (c *CatFile) fail(err error) error {
+	c.err = fmt.Errorf("cat-file: %w", err)
+	return c.err
+}
+
+// Close ends the session and waits for git to exit. It is safe to call
+// more than once.
+func (c *CatFile) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	close(c.done)
+	_ = c.stdin.Close()
+	err := c.cmd.Wait()
+	if c.err != nil || errors.Is(err, context.Canceled) {
+		return nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && !exitErr.Exited() {
+		// killed because the context ended
+		return nil
+	}
+	return err
+}
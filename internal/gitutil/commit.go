@@ -32,8 +32,10 @@ func FilesInCommit(dir, commit string) ([]string, error) {
 	return files, nil
 }
 
+// FileAtCommit reads a single file with a one-off git process. Callers that
+// read many files should use a CatFile session instead.
 func FileAtCommit(dir, commit, path string, maxBytes int64) (content string, truncated bool, err error) {
-	out, err := runGit(dir, "show", fmt.Sprintf("%s:%s", commit, path))
+	out, err := runGitRaw(dir, "show", fmt.Sprintf("%s:%s", commit, path))
 	if err != nil {
 		return "", false, err
 	}
@@ -1,16 +1,48 @@
 package gitutil
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
+// FileStatus describes what happened to a file in a commit.
+type FileStatus string
+
+const (
+	StatusAdded    FileStatus = "added"
+	StatusModified FileStatus = "modified"
+	StatusDeleted  FileStatus = "deleted"
+	StatusRenamed  FileStatus = "renamed"
+	StatusCopied   FileStatus = "copied"
+)
+
 type FileDiff struct {
-	Path   string
-	Hunks  []Hunk
-	Binary bool
+	Path       string
+	OldPath    string // differs from Path for renames and copies
+	Status     FileStatus
+	Similarity int    // similarity index (0-100) for renames and copies
+	OldMode    string // e.g. "100644"; empty when unknown
+	NewMode    string
+	Hunks      []Hunk
+	Binary     bool
+	// Merge is set when the hunks come from a merge commit and are not a
+	// plain diff against a single parent (see MergeMode).
+	Merge MergeMode
+}
+
+// ModeChanged reports whether the file mode differs between the two sides.
+func (fd FileDiff) ModeChanged() bool {
+	return fd.OldMode != "" && fd.NewMode != "" && fd.OldMode != fd.NewMode
+}
+
+// PureRename is true for renames/copies without any content change.
+func (fd FileDiff) PureRename() bool {
+	return (fd.Status == StatusRenamed || fd.Status == StatusCopied) && len(fd.Hunks) == 0 && !fd.Binary
 }
 
 type Hunk struct {
@@ -18,99 +50,444 @@ type Hunk struct {
 	OldLines int
 	NewStart int
 	NewLines int
-	Lines    []string
+	// Lines keeps the raw diff lines including their ' ', '+', '-' prefix.
+	// "\ No newline at end of file" markers are kept as-is.
+	Lines []string
 }
 
-func DiffHunks(dir, commit string, contextLines int) ([]FileDiff, error) {
-	args := []string{"show", "--no-color", "--format="}
-	if contextLines >= 0 {
-		args = append(args, fmt.Sprintf("-U%d", contextLines))
+// DiffOptions controls which files a diff stream produces.
+type DiffOptions struct {
+	Context int // context lines per hunk; negative uses git's default
+	// Pathspecs are passed to git so unwanted files are never generated.
+	Pathspecs []string
+	// Keep is consulted once a file's paths are known, before its hunks are
+	// read; rejected files are skipped without buffering their content.
+	Keep func(path string) bool
+	// MaxFileBytes limits the blobs read to tell conflict resolutions from
+	// evil changes (0 means no limit).
+	MaxFileBytes int64
+}
+
+func (o DiffOptions) contextArg() []string {
+	if o.Context < 0 {
+		return nil
 	}
-	args = append(args, commit)
+	return []string{fmt.Sprintf("-U%d", o.Context)}
+}
 
-	out, err := runGit(dir, args...)
-	if err != nil {
-		return nil, err
+func (o DiffOptions) pathspecArgs() []string {
+	if len(o.Pathspecs) == 0 {
+		return nil
 	}
-	if strings.TrimSpace(out) == "" {
-		return nil, nil
+	return append([]string{"--"}, o.Pathspecs...)
+}
+
+// ExtPathspecs turns extensions like ".go" into case-insensitive git
+// pathspecs matching those files in every directory.
+func ExtPathspecs(exts []string) []string {
+	out := make([]string, 0, len(exts))
+	for _, e := range exts {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		if !strings.HasPrefix(e, ".") {
+			e = "." + e
+		}
+		out = append(out, ":(glob,icase)**/*"+e)
 	}
+	return out
+}
 
-	sc := bufio.NewScanner(strings.NewReader(out))
-	sc.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+// StreamDiff streams the per-file diff of a (non-merge) commit to fn.
+// Deleted files are reported without their old content.
+func StreamDiff(ctx context.Context, dir, commit string, opt DiffOptions, fn func(FileDiff) error) error {
+	args := []string{"show", "--no-color", "--format=", "-M", "-C", "--irreversible-delete"}
+	args = append(args, opt.contextArg()...)
+	args = append(args, commit)
+	args = append(args, opt.pathspecArgs()...)
+	return streamDiff(ctx, dir, args, opt.Keep, fn)
+}
 
-	var diffs []FileDiff
-	var cur *FileDiff
+// StreamRangeDiff streams the combined change between two revisions.
+func StreamRangeDiff(ctx context.Context, dir, base, head string, opt DiffOptions, fn func(FileDiff) error) error {
+	args := []string{"diff", "--no-color", "-M", "-C", "--irreversible-delete"}
+	args = append(args, opt.contextArg()...)
+	args = append(args, base, head)
+	args = append(args, opt.pathspecArgs()...)
+	return streamDiff(ctx, dir, args, opt.Keep, fn)
+}
+
+// StreamStagedDiff streams the changes staged in the index.
+func StreamStagedDiff(ctx context.Context, dir string, opt DiffOptions, fn func(FileDiff) error) error {
+	args := []string{"diff", "--cached", "--no-color", "-M", "-C", "--irreversible-delete"}
+	args = append(args, opt.contextArg()...)
+	args = append(args, opt.pathspecArgs()...)
+	return streamDiff(ctx, dir, args, opt.Keep, fn)
+}
 
-	// regexi
-	reDiffHeader := regexp.MustCompile(`^diff --git a/(.+) b/(.+)$`)
-	reNewFile := regexp.MustCompile(`^\+\+\+ b/(.+)$`)
-	reBinary := regexp.MustCompile(`^Binary files .* and .* differ$`)
-	reHunk := regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+// DiffHunks collects StreamDiff into a slice.
+func DiffHunks(ctx context.Context, dir, commit string, opt DiffOptions) ([]FileDiff, error) {
+	var diffs []FileDiff
+	err := StreamDiff(ctx, dir, commit, opt, func(fd FileDiff) error {
+		diffs = append(diffs, fd)
+		return nil
+	})
+	return diffs, err
+}
 
-	var curHunk *Hunk
+func streamDiff(ctx context.Context, dir string, args []string, keep func(string) bool, fn func(FileDiff) error) error {
+	return streamGit(ctx, dir, args, func(r io.Reader) error {
+		return ParseDiff(r, keep, fn)
+	})
+}
 
-	flushHunk := func() {
-		if curHunk != nil && cur != nil {
-			cur.Hunks = append(cur.Hunks, *curHunk)
-			curHunk = nil
+// ParseDiff incrementally parses `git diff`/`git show` output, including
+// combined (`diff --cc`) output for merge commits, and calls emit for every
+// file as soon as it is complete. keep may be nil.
+func ParseDiff(r io.Reader, keep func(path string) bool, emit func(FileDiff) error) error {
+	p := &diffParser{keep: keep, emit: emit, parents: 1}
+	lr := newLineReader(r)
+	for {
+		line, err := lr.next()
+		if errors.Is(err, io.EOF) {
+			break
 		}
+		if err != nil {
+			return err
+		}
+		if err := p.line(line); err != nil {
+			return err
+		}
+	}
+	return p.flushFile()
+}
+
+// ParseHunks parses a patch without file headers, such as the per-file
+// patches of the GitHub pull request files API.
+func ParseHunks(patch string) []Hunk {
+	p := &diffParser{cur: &FileDiff{}, decided: true, parents: 1}
+	for _, line := range strings.Split(patch, "\n") {
+		_ = p.line(line)
+	}
+	p.flushHunk()
+	return p.cur.Hunks
+}
+
+var (
+	reDiffHeader     = regexp.MustCompile(`^diff --git (.+)$`)
+	reCombinedHeader = regexp.MustCompile(`^diff --(?:cc|combined) (.+)$`)
+	reBinary         = regexp.MustCompile(`^Binary files (.+) and (.+) differ$`)
+	reHunk           = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+	reCombinedHunk   = regexp.MustCompile(`^(@{3,}) (.+?) @{3,}`)
+	reIndex          = regexp.MustCompile(`^index [0-9a-f]+\.\.[0-9a-f]+(?: (\d+))?$`)
+)
+
+type diffParser struct {
+	keep func(string) bool
+	emit func(FileDiff) error
+
+	cur     *FileDiff
+	curHunk *Hunk
+	parents int // prefix columns per hunk line
+	decided bool
+	skip    bool // current file rejected by keep
+}
+
+// decide asks keep about the current file once its headers are complete.
+func (p *diffParser) decide() {
+	if p.decided || p.cur == nil {
+		return
+	}
+	p.decided = true
+	p.skip = p.keep != nil && !p.keep(p.cur.Path)
+}
+
+func (p *diffParser) flushHunk() {
+	if p.curHunk != nil && p.cur != nil && !p.skip {
+		p.cur.Hunks = append(p.cur.Hunks, *p.curHunk)
+	}
+	p.curHunk = nil
+}
+
+func (p *diffParser) flushFile() error {
+	p.flushHunk()
+	cur := p.cur
+	if cur == nil {
+		return nil
+	}
+	p.decide()
+	p.cur = nil
+	if p.skip {
+		return nil
+	}
+	if cur.Status == "" {
+		cur.Status = StatusModified
+	}
+	if cur.OldPath == "" {
+		cur.OldPath = cur.Path
+	}
+	return p.emit(*cur)
+}
+
+func (p *diffParser) startFile(fd *FileDiff) error {
+	if err := p.flushFile(); err != nil {
+		return err
 	}
+	p.cur = fd
+	p.decided, p.skip = false, false
+	p.parents = 1
+	return nil
+}
 
-	for sc.Scan() {
-		line := sc.Text()
+func (p *diffParser) line(line string) error {
+	if m := reDiffHeader.FindStringSubmatch(line); m != nil {
+		oldPath, newPath := splitDiffGitPaths(m[1])
+		return p.startFile(&FileDiff{Path: newPath, OldPath: oldPath})
+	}
+	if m := reCombinedHeader.FindStringSubmatch(line); m != nil {
+		path := unquotePath(m[1])
+		return p.startFile(&FileDiff{Path: path, OldPath: path, Merge: MergeConflicts})
+	}
+	cur := p.cur
+	if cur == nil {
+		return nil
+	}
 
-		if m := reDiffHeader.FindStringSubmatch(line); m != nil {
-			flushHunk()
-			if cur != nil {
-				diffs = append(diffs, *cur)
+	if p.curHunk != nil {
+		if isHunkLine(line, p.parents) {
+			if p.skip {
+				return nil
 			}
-			cur = &FileDiff{Path: m[2]}
-			continue
+			if p.parents > 1 && line[0] != '\\' {
+				line = normalizeCombinedLine(line, p.parents)
+			}
+			p.curHunk.Lines = append(p.curHunk.Lines, line)
+			return nil
 		}
+		p.flushHunk()
+	}
 
-		if reBinary.MatchString(line) {
-			if cur != nil {
-				cur.Binary = true
+	if m := reHunk.FindStringSubmatch(line); m != nil {
+		p.decide()
+		p.curHunk = &Hunk{
+			OldStart: atoiDefault(m[1]),
+			OldLines: countDefault(m[2]),
+			NewStart: atoiDefault(m[3]),
+			NewLines: countDefault(m[4]),
+		}
+		return nil
+	}
+	if m := reCombinedHunk.FindStringSubmatch(line); m != nil && cur.Merge != "" {
+		p.decide()
+		p.parents = len(m[1]) - 1
+		p.curHunk = &Hunk{}
+		for i, r := range strings.Fields(m[2]) {
+			start, count := parseRange(r[1:])
+			switch {
+			case r[0] == '+':
+				p.curHunk.NewStart, p.curHunk.NewLines = start, count
+			case i == 0:
+				// old side is reported against the first parent
+				p.curHunk.OldStart, p.curHunk.OldLines = start, count
 			}
-			continue
 		}
+		return nil
+	}
 
-		if m := reNewFile.FindStringSubmatch(line); m != nil {
-			if cur != nil {
-				cur.Path = m[1]
-			}
-			continue
+	switch {
+	case strings.HasPrefix(line, "old mode "):
+		cur.OldMode = strings.TrimPrefix(line, "old mode ")
+	case strings.HasPrefix(line, "new mode "):
+		cur.NewMode = strings.TrimPrefix(line, "new mode ")
+	case strings.HasPrefix(line, "new file mode "):
+		cur.Status = StatusAdded
+		cur.NewMode = strings.TrimPrefix(line, "new file mode ")
+	case strings.HasPrefix(line, "deleted file mode "):
+		cur.Status = StatusDeleted
+		cur.OldMode = strings.TrimPrefix(line, "deleted file mode ")
+	case strings.HasPrefix(line, "similarity index "):
+		cur.Similarity = atoiDefault(strings.TrimPrefix(line, "similarity index "))
+	case strings.HasPrefix(line, "rename from "):
+		cur.Status = StatusRenamed
+		cur.OldPath = unquotePath(strings.TrimPrefix(line, "rename from "))
+	case strings.HasPrefix(line, "rename to "):
+		cur.Status = StatusRenamed
+		cur.Path = unquotePath(strings.TrimPrefix(line, "rename to "))
+	case strings.HasPrefix(line, "copy from "):
+		cur.Status = StatusCopied
+		cur.OldPath = unquotePath(strings.TrimPrefix(line, "copy from "))
+	case strings.HasPrefix(line, "copy to "):
+		cur.Status = StatusCopied
+		cur.Path = unquotePath(strings.TrimPrefix(line, "copy to "))
+	case strings.HasPrefix(line, "--- "):
+		if path := diffSidePath(strings.TrimPrefix(line, "--- "), "a/"); path != "" {
+			cur.OldPath = path
 		}
+	case strings.HasPrefix(line, "+++ "):
+		if path := diffSidePath(strings.TrimPrefix(line, "+++ "), "b/"); path != "" {
+			cur.Path = path
+		}
+	case line == "GIT binary patch" || reBinary.MatchString(line):
+		cur.Binary = true
+	default:
+		if m := reIndex.FindStringSubmatch(line); m != nil && m[1] != "" {
+			// unchanged mode is reported on the index line
+			cur.OldMode, cur.NewMode = m[1], m[1]
+		}
+	}
+	return nil
+}
 
-		if m := reHunk.FindStringSubmatch(line); m != nil {
-			flushHunk()
-			curHunk = &Hunk{
-				OldStart: atoiDefault(m[1]),
-				OldLines: atoiDefault(m[2]),
-				NewStart: atoiDefault(m[3]),
-				NewLines: atoiDefault(m[4]),
-				Lines:    make([]string, 0, 64),
-			}
-			continue
+// isHunkLine reports whether line belongs to the current hunk body: one
+// ' ', '+' or '-' column per parent, or a "\ No newline" marker.
+func isHunkLine(line string, parents int) bool {
+	if len(line) > 0 && line[0] == '\\' {
+		return true
+	}
+	if len(line) < parents {
+		return false
+	}
+	for i := 0; i < parents; i++ {
+		switch line[i] {
+		case ' ', '+', '-':
+		default:
+			return false
 		}
+	}
+	return true
+}
+
+// normalizeCombinedLine folds the per-parent prefix columns into a single
+// unified-diff prefix: '-' when the line is not in the merge result, '+'
+// when it is new relative to at least one parent, ' ' otherwise.
+func normalizeCombinedLine(line string, parents int) string {
+	cols, text := line[:parents], line[parents:]
+	switch {
+	case strings.Contains(cols, "-"):
+		return "-" + text
+	case strings.Contains(cols, "+"):
+		return "+" + text
+	default:
+		return " " + text
+	}
+}
 
-		if curHunk != nil {
-			if len(line) > 0 {
-				switch line[0] {
-				case ' ', '+', '-':
-					curHunk.Lines = append(curHunk.Lines, line)
+func parseRange(s string) (start, count int) {
+	a, b, ok := strings.Cut(s, ",")
+	if !ok {
+		return atoiDefault(a), 1
+	}
+	return atoiDefault(a), atoiDefault(b)
+}
+
+// diffSidePath extracts the path from a ---/+++ line. It returns "" for
+// /dev/null so that the path from the header is kept.
+func diffSidePath(s, prefix string) string {
+	// git appends a tab when the name contains spaces
+	s = strings.TrimSuffix(s, "\t")
+	if s == "/dev/null" {
+		return ""
+	}
+	s = unquotePath(s)
+	return strings.TrimPrefix(s, prefix)
+}
+
+// splitDiffGitPaths parses the "a/<old> b/<new>" part of a diff --git header.
+// Unquoted paths may contain spaces, so when both sides are equal (the common
+// case) the header is split in the middle.
+func splitDiffGitPaths(s string) (oldPath, newPath string) {
+	if strings.HasPrefix(s, `"`) {
+		oldQ, rest := cutQuoted(s)
+		rest = strings.TrimPrefix(rest, " ")
+		oldPath = strings.TrimPrefix(unquotePath(oldQ), "a/")
+		newPath = strings.TrimPrefix(unquotePath(rest), "b/")
+		return oldPath, newPath
+	}
+	if strings.HasSuffix(s, `"`) {
+		if i := strings.Index(s, ` "`); i >= 0 {
+			return strings.TrimPrefix(s[:i], "a/"), strings.TrimPrefix(unquotePath(s[i+1:]), "b/")
+		}
+	}
+	if len(s)%2 == 1 {
+		half := len(s) / 2
+		a, b := s[:half], s[half+1:]
+		if strings.HasPrefix(a, "a/") && strings.HasPrefix(b, "b/") && a[2:] == b[2:] {
+			return a[2:], b[2:]
+		}
+	}
+	// renames with spaces are resolved later by "rename from/to" lines
+	if i := strings.Index(s, " b/"); i >= 0 {
+		return strings.TrimPrefix(s[:i], "a/"), s[i+3:]
+	}
+	return s, s
+}
+
+// cutQuoted splits a leading C-quoted string from the rest of s.
+func cutQuoted(s string) (quoted, rest string) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return s[:i+1], s[i+1:]
+		}
+	}
+	return s, ""
+}
+
+// unquotePath decodes git's C-style quoting ("a/t\303\244st \"x\"").
+// Octal escapes are raw bytes, which together form UTF-8.
+func unquotePath(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	s = s[1 : len(s)-1]
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 >= len(s) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'v':
+			b.WriteByte('\v')
+		case '0', '1', '2', '3':
+			if i+2 < len(s) {
+				if n, err := strconv.ParseUint(s[i:i+3], 8, 8); err == nil {
+					b.WriteByte(byte(n))
+					i += 2
 					continue
 				}
 			}
-			flushHunk()
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte(s[i])
 		}
 	}
-	flushHunk()
-	if cur != nil {
-		diffs = append(diffs, *cur)
+	return b.String()
+}
+
+// countDefault parses a hunk line count; an omitted count means 1.
+func countDefault(s string) int {
+	if s == "" {
+		return 1
 	}
-	return diffs, nil
+	return atoiDefault(s)
 }
 
 func atoiDefault(s string) int {
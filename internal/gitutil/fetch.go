@@ -0,0 +1,61 @@
+package gitutil
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Fetch fetches refspecs from remote without touching local branches.
+func Fetch(ctx context.Context, dir, remote string, refspecs ...string) error {
+	args := append([]string{"fetch", "--no-tags", "--quiet", remote}, refspecs...)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var errb bytes.Buffer
+	cmd.Stderr = &errb
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %v: %v (%s)", args, err, strings.TrimSpace(errb.String()))
+	}
+	return nil
+}
+
+// HasCommit reports whether sha is a commit in the local object store.
+func HasCommit(dir, sha string) bool {
+	_, err := runGit(dir, "cat-file", "-e", sha+"^{commit}")
+	return err == nil
+}
+
+// MergeBase returns the best common ancestor of a and b, the base GitHub
+// diffs a pull request against.
+func MergeBase(dir, a, b string) (string, error) {
+	return runGit(dir, "merge-base", a, b)
+}
+
+// FileFate tells what became of path between from and to: its new path
+// when it was renamed (or moved), or deleted when it was removed. Both are
+// zero when path still exists in to.
+func FileFate(dir, from, to, path string) (renamed string, deleted bool, err error) {
+	// no pathspec: rename detection needs to see the new path too
+	out, err := runGitRaw(dir, "diff", "-M", "--name-status", "-z", "--no-color", "--diff-filter=DR", from, to)
+	if err != nil {
+		return "", false, err
+	}
+	f := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	for i := 0; i < len(f); i++ {
+		switch {
+		case strings.HasPrefix(f[i], "R") && i+2 < len(f):
+			if f[i+1] == path {
+				return f[i+2], false, nil
+			}
+			i += 2
+		case f[i] == "D" && i+1 < len(f):
+			if f[i+1] == path {
+				return "", true, nil
+			}
+			i++
+		}
+	}
+	return "", false, nil
+}
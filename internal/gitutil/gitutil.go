@@ -2,13 +2,25 @@ package gitutil
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
-	"regexp"
+	"path/filepath"
 	"strings"
 )
 
 func runGit(dir string, args ...string) (string, error) {
+	out, err := runGitRaw(dir, args...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// runGitRaw returns stdout untouched, for file contents where leading
+// whitespace matters.
+func runGitRaw(dir string, args ...string) (string, error) {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = dir
 	var out, errb bytes.Buffer
@@ -17,7 +29,25 @@ func runGit(dir string, args ...string) (string, error) {
 	if err := cmd.Run(); err != nil {
 		return "", fmt.Errorf("git %v: %v (%s)", args, err, strings.TrimSpace(errb.String()))
 	}
-	return strings.TrimSpace(out.String()), nil
+	return out.String(), nil
+}
+
+// runGitStatus is like runGit but returns the exit code instead of failing
+// for commands that use non-zero codes to report a result.
+func runGitStatus(dir string, args ...string) (string, int, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var out, errb bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &errb
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return strings.TrimSpace(out.String()), exitErr.ExitCode(), fmt.Errorf("git %v: %v (%s)", args, err, strings.TrimSpace(errb.String()))
+		}
+		return "", -1, fmt.Errorf("git %v: %v", args, err)
+	}
+	return strings.TrimSpace(out.String()), 0, nil
 }
 
 func IsRepo(dir string) bool {
@@ -29,6 +59,65 @@ func RepoRoot(dir string) (string, error) {
 	return runGit(dir, "rev-parse", "--show-toplevel")
 }
 
+// ZeroSHA is what git passes to hooks for a ref that does not exist.
+const ZeroSHA = "0000000000000000000000000000000000000000"
+
+// HooksDir returns the absolute hooks directory, honouring core.hooksPath.
+func HooksDir(dir string) (string, error) {
+	if p, err := runGit(dir, "config", "--get", "core.hooksPath"); err == nil && p != "" {
+		if strings.HasPrefix(p, "~/") {
+			if home, herr := os.UserHomeDir(); herr == nil {
+				p = filepath.Join(home, p[2:])
+			}
+		}
+		if !filepath.IsAbs(p) {
+			// relative hooksPath is resolved against the work tree root
+			root, err := RepoRoot(dir)
+			if err != nil {
+				return "", err
+			}
+			p = filepath.Join(root, p)
+		}
+		return p, nil
+	}
+	p, err := runGit(dir, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	return p, nil
+}
+
+// OutgoingBase picks the revision to diff a pushed ref against when the
+// remote side does not exist yet: the parent of the oldest commit that no
+// remote-tracking branch contains. It returns "" for a root commit, in which
+// case the whole history is new.
+func OutgoingBase(dir, head string) (string, error) {
+	out, err := runGit(dir, "rev-list", "--reverse", "--topo-order", head, "--not", "--remotes")
+	if err != nil {
+		return "", err
+	}
+	if out == "" {
+		return head, nil // nothing new
+	}
+	oldest, _, _ := strings.Cut(out, "\n")
+	parents, err := Parents(dir, oldest)
+	if err != nil {
+		return "", err
+	}
+	if len(parents) == 0 {
+		return "", nil
+	}
+	return parents[0], nil
+}
+
+// EmptyTree returns the id of the empty tree, usable as a diff base.
+func EmptyTree(dir string) (string, error) {
+	return runGit(dir, "hash-object", "-t", "tree", "--stdin")
+}
+
 func RemoteOriginURL(dir string) (string, bool) {
 	u, err := runGit(dir, "remote", "get-url", "origin")
 	if err != nil || strings.TrimSpace(u) == "" {
@@ -37,15 +126,8 @@ func RemoteOriginURL(dir string) (string, bool) {
 	return u, true
 }
 
-// SSH or HTTPS GitHub URL
-func ParseOwnerRepo(remote string) (owner, repo string) {
-	ssh := regexp.MustCompile(`git@[^:]+:([^/]+)/([^\.]+)(?:\.git)?$`)
-	http := regexp.MustCompile(`https?://[^/]+/([^/]+)/([^\.]+)(?:\.git)?$`)
-	if m := ssh.FindStringSubmatch(remote); len(m) == 3 {
-		return m[1], m[2]
-	}
-	if m := http.FindStringSubmatch(remote); len(m) == 3 {
-		return m[1], m[2]
-	}
-	return "", ""
+// Editor returns the editor git would use (GIT_EDITOR, core.editor,
+// VISUAL, EDITOR, then vi).
+func Editor(dir string) (string, error) {
+	return runGit(dir, "var", "GIT_EDITOR")
 }
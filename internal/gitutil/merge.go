@@ -0,0 +1,181 @@
+package gitutil
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MergeMode selects how a merge commit is turned into hunks.
+type MergeMode string
+
+const (
+	// MergeFirstParent diffs the merge against its first parent, i.e. the
+	// whole change the merge brought into the target branch.
+	MergeFirstParent MergeMode = "first-parent"
+	// MergeConflicts uses git's dense combined diff (--cc), which only keeps
+	// hunks where the result differs from every parent: the places where
+	// conflicts were resolved by hand.
+	MergeConflicts MergeMode = "conflicts"
+	// MergeEvil reports changes that are present in neither parent and are
+	// not part of resolving a conflict.
+	MergeEvil MergeMode = "evil"
+)
+
+var MergeModes = []MergeMode{MergeFirstParent, MergeConflicts, MergeEvil}
+
+func ParseMergeMode(s string) (MergeMode, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return MergeFirstParent, nil
+	}
+	for _, m := range MergeModes {
+		if string(m) == s {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("unknown merge mode %q (use first-parent, conflicts or evil)", s)
+}
+
+// Parents returns the parent commits of commit (more than one for merges).
+func Parents(dir, commit string) ([]string, error) {
+	out, err := runGit(dir, "rev-list", "--parents", "-n", "1", commit)
+	if err != nil {
+		return nil, err
+	}
+	f := strings.Fields(out)
+	if len(f) == 0 {
+		return nil, fmt.Errorf("unknown commit %s", commit)
+	}
+	return f[1:], nil
+}
+
+// StreamMergeDiff streams the per-file diff of a merge commit according to
+// mode.
+func StreamMergeDiff(ctx context.Context, dir, commit string, mode MergeMode, opt DiffOptions, fn func(FileDiff) error) error {
+	switch mode {
+	case MergeFirstParent, "":
+		args := []string{"diff", "--no-color", "-M", "-C", "--irreversible-delete"}
+		args = append(args, opt.contextArg()...)
+		args = append(args, commit+"^1", commit)
+		args = append(args, opt.pathspecArgs()...)
+		return streamDiff(ctx, dir, args, opt.Keep, fn)
+	case MergeConflicts:
+		args := []string{"show", "--no-color", "--format=", "--cc"}
+		args = append(args, opt.contextArg()...)
+		args = append(args, commit)
+		args = append(args, opt.pathspecArgs()...)
+		return streamDiff(ctx, dir, args, opt.Keep, fn)
+	case MergeEvil:
+		return streamEvilMerge(ctx, dir, commit, opt, fn)
+	default:
+		return fmt.Errorf("unknown merge mode %q", mode)
+	}
+}
+
+// streamEvilMerge re-does the merge with `git merge-tree` and diffs the
+// automatic result against the recorded merge. Hunks that fall inside a
+// conflict region of the automatic result are resolutions; everything else
+// was introduced by the merge itself. Hunks are produced without context so
+// that resolutions and evil changes are not folded together. Conflicted
+// files too large or binary to scan for markers are skipped.
+func streamEvilMerge(ctx context.Context, dir, commit string, opt DiffOptions, fn func(FileDiff) error) error {
+	parents, err := Parents(dir, commit)
+	if err != nil {
+		return err
+	}
+	if len(parents) != 2 {
+		return fmt.Errorf("evil merge detection needs exactly two parents, %s has %d", commit, len(parents))
+	}
+
+	// exit code 1 means "merged with conflicts", which is expected here
+	out, code, err := runGitStatus(dir, "merge-tree", "--write-tree", "--no-messages", parents[0], parents[1])
+	if code < 0 || code > 1 {
+		return fmt.Errorf("git merge-tree (requires git 2.38+): %v", err)
+	}
+	sc := bufio.NewScanner(strings.NewReader(out))
+	var tree string
+	conflicted := map[string]struct{}{}
+	for sc.Scan() {
+		line := sc.Text()
+		if tree == "" {
+			tree = strings.TrimSpace(line)
+			continue
+		}
+		if _, p, ok := strings.Cut(line, "\t"); ok {
+			conflicted[unquotePath(p)] = struct{}{}
+		}
+	}
+	if tree == "" {
+		return fmt.Errorf("git merge-tree returned no tree")
+	}
+
+	var blobs *CatFile
+	if len(conflicted) > 0 {
+		if blobs, err = NewCatFile(ctx, dir, opt.MaxFileBytes); err != nil {
+			return err
+		}
+		defer blobs.Close()
+	}
+
+	args := []string{"diff", "--no-color", "-U0", tree, commit}
+	args = append(args, opt.pathspecArgs()...)
+	return streamDiff(ctx, dir, args, opt.Keep, func(fd FileDiff) error {
+		var regions [][2]int
+		if _, ok := conflicted[fd.OldPath]; ok {
+			b, err := blobs.Read(tree, fd.OldPath)
+			if err == nil && (b.Truncated || b.Binary) {
+				return nil
+			}
+			if err == nil {
+				regions = conflictRegions(string(b.Content))
+			}
+		}
+		kept := fd.Hunks[:0]
+		for _, h := range fd.Hunks {
+			if !insideRegions(h, regions) {
+				kept = append(kept, h)
+			}
+		}
+		if len(kept) == 0 && !fd.Binary && !fd.ModeChanged() {
+			return nil
+		}
+		fd.Hunks = kept
+		fd.Merge = MergeEvil
+		return fn(fd)
+	})
+}
+
+// conflictRegions returns 1-based inclusive line ranges of conflict markers.
+func conflictRegions(content string) [][2]int {
+	var regions [][2]int
+	start := 0
+	for i, l := range strings.Split(content, "\n") {
+		switch {
+		case strings.HasPrefix(l, "<<<<<<< ") && start == 0:
+			start = i + 1
+		case strings.HasPrefix(l, ">>>>>>> ") && start != 0:
+			regions = append(regions, [2]int{start, i + 1})
+			start = 0
+		}
+	}
+	return regions
+}
+
+// insideRegions reports whether the old side of h touches a conflict region.
+func insideRegions(h Hunk, regions [][2]int) bool {
+	for _, r := range regions {
+		if h.OldLines == 0 {
+			// pure insertion after line OldStart
+			if h.OldStart >= r[0] && h.OldStart < r[1] {
+				return true
+			}
+			continue
+		}
+		if h.OldStart <= r[1] && h.OldStart+h.OldLines-1 >= r[0] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package gitutil
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DiffContents returns the hunks that turn old into new, with ctxLines
+// lines of context.
+func DiffContents(ctx context.Context, old, new string, ctxLines int) ([]Hunk, error) {
+	if old == new {
+		return nil, nil
+	}
+	dir, err := os.MkdirTemp("", "dai-diff-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	if err := os.WriteFile(filepath.Join(dir, "a"), []byte(old), 0o600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b"), []byte(new), 0o600); err != nil {
+		return nil, err
+	}
+	var hunks []Hunk
+	args := []string{"diff", "--no-index", "--no-color", "--no-ext-diff", fmt.Sprintf("-U%d", ctxLines), "--", "a", "b"}
+	err = streamGit(ctx, dir, args, func(r io.Reader) error {
+		return ParseDiff(r, nil, func(fd FileDiff) error {
+			hunks = append(hunks, fd.Hunks...)
+			return nil
+		})
+	})
+	// --no-index exits with 1 when the files differ
+	if len(hunks) == 0 {
+		if err == nil {
+			err = fmt.Errorf("git %v: no hunks", args)
+		}
+		return nil, err
+	}
+	return hunks, nil
+}
+
+// ApplyHunks applies hunks, sorted and taken from one diff of content, to
+// content. Hunks left out keep their old lines.
+func ApplyHunks(content string, hunks []Hunk) (string, error) {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	var sb strings.Builder
+	next := 0 // index of the first old line not copied yet
+	for _, h := range hunks {
+		start := h.OldStart - 1
+		if h.OldLines == 0 {
+			start = h.OldStart // insertion after line OldStart
+		}
+		if start < next || start > len(lines) {
+			return "", fmt.Errorf("hunk @@ -%d,%d @@ overlaps another or is out of range", h.OldStart, h.OldLines)
+		}
+		for ; next < start; next++ {
+			sb.WriteString(lines[next])
+		}
+		for i, l := range h.Lines {
+			if l == "" || l[0] == '\\' {
+				continue
+			}
+			noEOL := i+1 < len(h.Lines) && strings.HasPrefix(h.Lines[i+1], "\\")
+			switch l[0] {
+			case ' ', '-':
+				if next >= len(lines) || strings.TrimSuffix(lines[next], "\n") != l[1:] {
+					return "", fmt.Errorf("hunk @@ -%d,%d @@ does not match line %d", h.OldStart, h.OldLines, next+1)
+				}
+				if l[0] == ' ' {
+					sb.WriteString(lines[next])
+				}
+				next++
+			case '+':
+				sb.WriteString(l[1:])
+				if !noEOL {
+					sb.WriteString("\n")
+				}
+			}
+		}
+	}
+	for ; next < len(lines); next++ {
+		sb.WriteString(lines[next])
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,151 @@
+package gitutil
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Remote is a parsed git remote URL.
+type Remote struct {
+	// Host is where the web/API server lives. It keeps the port of http(s)
+	// URLs but drops the port of ssh URLs, which says nothing about the API.
+	Host string
+	// Namespace is everything between host and repo: "owner" on GitHub,
+	// "group/sub" on GitLab, "org/project" on Azure DevOps.
+	Namespace string
+	Repo      string
+}
+
+// Path returns "namespace/repo".
+func (r Remote) Path() string {
+	return r.Namespace + "/" + r.Repo
+}
+
+// ParseRemote understands scp-like ssh remotes (git@host:ns/repo.git),
+// ssh://, git://, http(s):// URLs with optional user and port, nested
+// namespaces (GitLab subgroups), Bitbucket Server /scm/ paths and Azure
+// DevOps URLs.
+func ParseRemote(raw string) (Remote, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return Remote{}, fmt.Errorf("empty remote URL")
+	}
+
+	var host, path string
+	keepPort := false
+	if i := strings.Index(s, "://"); i >= 0 {
+		u, err := url.Parse(s)
+		if err != nil {
+			return Remote{}, fmt.Errorf("parse remote %q: %w", raw, err)
+		}
+		switch strings.ToLower(u.Scheme) {
+		case "https", "http":
+			keepPort = true
+		case "ssh", "git", "git+ssh", "ssh+git":
+		default:
+			return Remote{}, fmt.Errorf("unsupported remote scheme %q in %s", u.Scheme, raw)
+		}
+		host = u.Hostname()
+		if keepPort && u.Port() != "" {
+			host += ":" + u.Port()
+		}
+		path = u.Path
+	} else {
+		// scp-like: [user@]host:path
+		colon := strings.Index(s, ":")
+		slash := strings.Index(s, "/")
+		// a single letter before the colon is a Windows drive
+		if colon <= 1 || (slash >= 0 && slash < colon) {
+			return Remote{}, fmt.Errorf("not a remote URL (local path?): %s", raw)
+		}
+		host = s[:colon]
+		if at := strings.LastIndex(host, "@"); at >= 0 {
+			host = host[at+1:]
+		}
+		path = s[colon+1:]
+	}
+
+	host = strings.ToLower(host)
+	segs := splitPath(path)
+	segs, host = normalizeHostPath(host, segs)
+	if len(segs) < 2 {
+		return Remote{}, fmt.Errorf("remote URL has no namespace/repo: %s", raw)
+	}
+	repo := strings.TrimSuffix(segs[len(segs)-1], ".git")
+	if repo == "" {
+		return Remote{}, fmt.Errorf("remote URL has no repository name: %s", raw)
+	}
+	return Remote{
+		Host:      host,
+		Namespace: strings.Join(segs[:len(segs)-1], "/"),
+		Repo:      repo,
+	}, nil
+}
+
+func splitPath(p string) []string {
+	var out []string
+	for _, s := range strings.Split(p, "/") {
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// normalizeHostPath removes path segments that are not part of the
+// namespace and maps ssh-only hosts to their web host.
+func normalizeHostPath(host string, segs []string) ([]string, string) {
+	switch {
+	// git@ssh.dev.azure.com:v3/org/project/repo
+	case host == "ssh.dev.azure.com" || host == "vs-ssh.visualstudio.com":
+		if len(segs) > 0 && segs[0] == "v3" {
+			segs = segs[1:]
+		}
+		return segs, "dev.azure.com"
+	// https://dev.azure.com/org/project/_git/repo
+	// https://org.visualstudio.com/[DefaultCollection/]project/_git/repo
+	case host == "dev.azure.com" || strings.HasSuffix(host, ".visualstudio.com"):
+		out := make([]string, 0, len(segs))
+		for _, s := range segs {
+			if s != "_git" {
+				out = append(out, s)
+			}
+		}
+		if org, ok := strings.CutSuffix(host, ".visualstudio.com"); ok {
+			if len(out) > 0 && strings.EqualFold(out[0], "DefaultCollection") {
+				out = out[1:]
+			}
+			out = append([]string{org}, out...)
+			host = "dev.azure.com"
+		}
+		return out, host
+	}
+	// Bitbucket Server: https://host/scm/PROJECT/repo.git
+	if len(segs) > 2 && segs[0] == "scm" {
+		segs = segs[1:]
+	}
+	return segs, host
+}
+
+// GuessProvider maps well-known hosts (and hosts whose name says so) to a
+// provider name; it returns "" when the host gives no hint.
+func GuessProvider(host string) string {
+	h := strings.ToLower(host)
+	if i := strings.Index(h, ":"); i >= 0 {
+		h = h[:i]
+	}
+	switch {
+	case h == "github.com" || strings.HasSuffix(h, ".ghe.com"):
+		return "github"
+	case h == "gitlab.com" || strings.Contains(h, "gitlab"):
+		return "gitlab"
+	case h == "bitbucket.org" || strings.Contains(h, "bitbucket"):
+		return "bitbucket"
+	case h == "dev.azure.com":
+		return "azure"
+	case h == "codeberg.org" || strings.Contains(h, "gitea") || strings.Contains(h, "forgejo"):
+		return "gitea"
+	}
+	return ""
+}
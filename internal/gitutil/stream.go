@@ -0,0 +1,88 @@
+package gitutil
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// maxLineBytes caps a single diff line kept in memory; the rest of an
+// overlong line (minified bundles, generated data) is dropped.
+const maxLineBytes = 1 << 20
+
+// streamGit runs git and hands its stdout pipe to fn while the process is
+// still running. The process is killed when ctx is cancelled or when fn
+// returns early with an error.
+func streamGit(ctx context.Context, dir string, args []string, fn func(io.Reader) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var errb bytes.Buffer
+	cmd.Stderr = &errb
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("git %v: %w", args, err)
+	}
+
+	fnErr := fn(stdout)
+	if fnErr != nil {
+		cancel()
+	} else {
+		// let git finish even if fn stopped reading before EOF
+		_, _ = io.Copy(io.Discard, stdout)
+	}
+	waitErr := cmd.Wait()
+
+	switch {
+	case fnErr != nil:
+		return fnErr
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case waitErr != nil:
+		return fmt.Errorf("git %v: %v (%s)", args, waitErr, strings.TrimSpace(errb.String()))
+	}
+	return nil
+}
+
+// lineReader reads '\n'-terminated lines without the size limit of
+// bufio.Scanner; bytes beyond maxLineBytes are discarded.
+type lineReader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{r: bufio.NewReaderSize(r, 64*1024)}
+}
+
+func (lr *lineReader) next() (string, error) {
+	lr.buf = lr.buf[:0]
+	for {
+		chunk, isPrefix, err := lr.r.ReadLine()
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(lr.buf) > 0 {
+				return string(lr.buf), nil
+			}
+			return "", err
+		}
+		if room := maxLineBytes - len(lr.buf); room > 0 {
+			if len(chunk) > room {
+				chunk = chunk[:room]
+			}
+			lr.buf = append(lr.buf, chunk...)
+		}
+		if !isPrefix {
+			return string(lr.buf), nil
+		}
+	}
+}
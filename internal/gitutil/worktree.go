@@ -0,0 +1,177 @@
+package gitutil
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Worktree is a throwaway checkout of a repository in a temporary
+// directory, so changes can be made without touching the user's one.
+type Worktree struct {
+	Dir  string
+	repo string
+}
+
+// AddWorktree checks rev out, detached, into a new temporary directory.
+// Callers must Remove it.
+func AddWorktree(ctx context.Context, repo, rev string) (*Worktree, error) {
+	dir, err := os.MkdirTemp("", "dai-worktree-")
+	if err != nil {
+		return nil, err
+	}
+	if err := runGitCtx(ctx, repo, "worktree", "add", "--detach", "--quiet", dir, rev); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return &Worktree{Dir: dir, repo: repo}, nil
+}
+
+// Remove deletes the checkout and git's record of it. Branches created in
+// it are kept.
+func (w *Worktree) Remove() error {
+	_, err := runGit(w.repo, "worktree", "remove", "--force", w.Dir)
+	if err != nil {
+		os.RemoveAll(w.Dir)
+		_, _ = runGit(w.repo, "worktree", "prune")
+	}
+	return err
+}
+
+// Diff stages everything and returns the patch against the checked-out
+// commit.
+func (w *Worktree) Diff() (string, error) {
+	if _, err := runGit(w.Dir, "add", "-A"); err != nil {
+		return "", err
+	}
+	return runGitRaw(w.Dir, "diff", "--cached", "--no-color", "--no-ext-diff")
+}
+
+// Commit commits everything on a new branch and returns the commit id.
+// Hooks are skipped: dai's own hooks would triage the fix again.
+func (w *Worktree) Commit(ctx context.Context, branch, message string) (string, error) {
+	if err := runGitCtx(ctx, w.Dir, "switch", "--quiet", "-c", branch); err != nil {
+		return "", err
+	}
+	if _, err := runGit(w.Dir, "add", "-A"); err != nil {
+		return "", err
+	}
+	if err := runGitCtx(ctx, w.Dir, "commit", "--quiet", "--no-verify", "-m", message); err != nil {
+		return "", err
+	}
+	return HeadCommit(w.Dir)
+}
+
+// Push pushes branch to remote, without running the pre-push hook.
+func Push(ctx context.Context, dir, remote, branch string) error {
+	return runGitCtx(ctx, dir, "push", "--quiet", "--no-verify", remote, "refs/heads/"+branch+":refs/heads/"+branch)
+}
+
+// DefaultBranch returns the branch origin/HEAD points to, or the current
+// branch when the remote's default is unknown.
+func DefaultBranch(dir string) (string, error) {
+	if ref, err := runGit(dir, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"); err == nil && ref != "" {
+		return strings.TrimPrefix(ref, "origin/"), nil
+	}
+	b, err := runGit(dir, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("no default branch: origin/HEAD is not set and HEAD is detached")
+	}
+	return b, nil
+}
+
+func runGitCtx(ctx context.Context, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var errb bytes.Buffer
+	cmd.Stderr = &errb
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %v: %v (%s)", args, err, strings.TrimSpace(errb.String()))
+	}
+	return nil
+}
+
+// BranchExists reports whether dir has a local branch of that name.
+func BranchExists(dir, branch string) bool {
+	_, err := runGit(dir, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	return err == nil
+}
+
+// RemoteBranchExists asks remote whether it has branch.
+func RemoteBranchExists(ctx context.Context, dir, remote, branch string) (bool, error) {
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", "--heads", remote, "refs/heads/"+branch)
+	cmd.Dir = dir
+	var out, errb bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errb
+	if err := cmd.Run(); err != nil {
+		return false, fmt.Errorf("git ls-remote %s: %v (%s)", remote, err, strings.TrimSpace(errb.String()))
+	}
+	return strings.TrimSpace(out.String()) != "", nil
+}
+
+// DeleteBranch detaches the worktree from branch and deletes it.
+func (w *Worktree) DeleteBranch(branch string) error {
+	if _, err := runGit(w.Dir, "switch", "--quiet", "--detach"); err != nil {
+		return err
+	}
+	_, err := runGit(w.Dir, "branch", "--quiet", "-D", branch)
+	return err
+}
+
+// Snapshot returns a commit holding the working tree's tracked changes
+// (like git stash, without touching anything), or HEAD when there are
+// none. Untracked files are not included; see CopyUntracked.
+func Snapshot(dir string) (string, error) {
+	sha, err := runGit(dir, "stash", "create")
+	if err != nil {
+		return "", err
+	}
+	if sha == "" {
+		return HeadCommit(dir)
+	}
+	return sha, nil
+}
+
+// CopyUntracked copies the untracked, not ignored files of the working tree
+// at src into the worktree, so it matches src like Snapshot alone cannot.
+func (w *Worktree) CopyUntracked(src string) error {
+	out, err := runGitRaw(src, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return err
+	}
+	for _, p := range strings.Split(out, "\x00") {
+		if p == "" {
+			continue
+		}
+		from, to := filepath.Join(src, filepath.FromSlash(p)), filepath.Join(w.Dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+			return err
+		}
+		st, err := os.Lstat(from)
+		if err != nil {
+			return err
+		}
+		if st.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(from)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(target, to); err != nil {
+				return err
+			}
+			continue
+		}
+		b, err := os.ReadFile(from)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(to, b, st.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Names of the hooks dai can manage.
+const (
+	PreCommit = "pre-commit"
+	PrePush   = "pre-push"
+)
+
+var Names = []string{PreCommit, PrePush}
+
+// SkipEnv bypasses the hooks when set to a non-empty value.
+const SkipEnv = "DAI_SKIP_HOOKS"
+
+// marker identifies hook scripts written by dai.
+const marker = "# dai-hook: managed by `dai hook install`"
+
+// backupSuffix is appended to a foreign hook that --force replaced.
+const backupSuffix = ".pre-dai"
+
+// Status of one hook file.
+type Status struct {
+	Name      string
+	Path      string
+	Installed bool // a dai script is in place
+	Foreign   bool // some other script is in place
+	Backup    bool // a replaced foreign script was kept
+}
+
+func IsValid(name string) bool {
+	for _, n := range Names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Script renders the hook. exe is used when `dai` is not on PATH.
+func Script(name, exe string) string {
+	return fmt.Sprintf(`#!/bin/sh
+%s — remove with `+"`dai hook uninstall`"+`
+# Bypass once with %s=1 (or git's --no-verify).
+if [ -n "$%s" ]; then
+  exit 0
+fi
+DAI=dai
+command -v "$DAI" >/dev/null 2>&1 || DAI=%s
+exec "$DAI" hook run %s "$@"
+`, marker, SkipEnv, SkipEnv, shellQuote(filepath.ToSlash(exe)), name)
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Install writes the hook into dir. A foreign hook is only replaced when
+// force is set, and is kept next to it with a .pre-dai suffix.
+func Install(dir, name, exe string, force bool) (string, error) {
+	if !IsValid(name) {
+		return "", fmt.Errorf("unknown hook %q", name)
+	}
+	st, err := Check(dir, name)
+	if err != nil {
+		return "", err
+	}
+	if st.Foreign {
+		if !force {
+			return "", fmt.Errorf("%s already exists and was not written by dai (use --force to replace it; the original is kept as %s%s)", st.Path, name, backupSuffix)
+		}
+		if err := os.Rename(st.Path, st.Path+backupSuffix); err != nil {
+			return "", err
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(st.Path, []byte(Script(name, exe)), 0o755); err != nil {
+		return "", err
+	}
+	return st.Path, nil
+}
+
+// Uninstall removes a dai hook and restores the hook it replaced, if any.
+// Foreign hooks are left alone.
+func Uninstall(dir, name string) (bool, error) {
+	st, err := Check(dir, name)
+	if err != nil {
+		return false, err
+	}
+	if !st.Installed {
+		return false, nil
+	}
+	if err := os.Remove(st.Path); err != nil {
+		return false, err
+	}
+	if st.Backup {
+		if err := os.Rename(st.Path+backupSuffix, st.Path); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+func Check(dir, name string) (Status, error) {
+	st := Status{Name: name, Path: filepath.Join(dir, name)}
+	b, err := os.ReadFile(st.Path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return st, err
+	case strings.Contains(string(b), marker):
+		st.Installed = true
+	default:
+		st.Foreign = true
+	}
+	if _, err := os.Stat(st.Path + backupSuffix); err == nil {
+		st.Backup = true
+	}
+	return st, nil
+}
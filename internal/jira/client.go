@@ -0,0 +1,193 @@
+// Package jira creates issues through the REST API (v2) of Jira Cloud and
+// Jira Server/Data Center.
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const requestTimeout = 30 * time.Second
+
+// Typed errors, matched with errors.Is against an *APIError.
+var (
+	ErrUnauthorized = errors.New("jira: unauthorized (check the token)")
+	ErrBadRequest   = errors.New("jira: bad request")
+)
+
+// APIError is a non-2xx response. Messages collects errorMessages and the
+// per-field errors Jira reports.
+type APIError struct {
+	StatusCode int
+	Method     string
+	URL        string
+	Messages   []string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("jira %s %s: %d: %s", e.Method, e.URL, e.StatusCode, strings.Join(e.Messages, "; "))
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	}
+	return false
+}
+
+// Client talks to one Jira site.
+type Client struct {
+	base string
+	auth string
+	hc   *http.Client
+}
+
+// NewClient creates a client for a site such as https://acme.atlassian.net.
+// A token of the form "email:api-token" authenticates with basic auth
+// (Jira Cloud); anything else is sent as a bearer token (a Server/Data
+// Center personal access token).
+func NewClient(base, token string) *Client {
+	token = strings.TrimSpace(token)
+	auth := "Bearer " + token
+	if strings.Contains(token, ":") {
+		auth = "Basic " + base64.StdEncoding.EncodeToString([]byte(token))
+	}
+	return &Client{
+		base: strings.TrimRight(base, "/"),
+		auth: auth,
+		hc:   &http.Client{Timeout: requestTimeout},
+	}
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	u := c.base + path
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", c.auth)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dai-cli/triage")
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if out != nil {
+			return json.NewDecoder(resp.Body).Decode(out)
+		}
+		return nil
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	return &APIError{StatusCode: resp.StatusCode, Method: method, URL: u, Messages: errorMessages(raw)}
+}
+
+func errorMessages(raw []byte) []string {
+	var body struct {
+		ErrorMessages []string          `json:"errorMessages"`
+		Errors        map[string]string `json:"errors"`
+	}
+	if json.Unmarshal(raw, &body) != nil {
+		return []string{strings.TrimSpace(string(raw))}
+	}
+	out := body.ErrorMessages
+	for field, msg := range body.Errors {
+		out = append(out, field+": "+msg)
+	}
+	return out
+}
+
+// NewIssue is an issue to create. Priority is a priority name and may be
+// empty to use the project default.
+type NewIssue struct {
+	Project     string // project key
+	IssueType   string
+	Summary     string
+	Description string // wiki markup
+	Priority    string
+	Labels      []string
+}
+
+type named struct {
+	Name string `json:"name"`
+}
+
+type issueFields struct {
+	Project struct {
+		Key string `json:"key"`
+	} `json:"project"`
+	IssueType   named    `json:"issuetype"`
+	Summary     string   `json:"summary"`
+	Description string   `json:"description,omitempty"`
+	Priority    *named   `json:"priority,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+}
+
+// MaxSummary is the longest summary (in characters) Jira accepts.
+const MaxSummary = 255
+
+// CreateIssue creates an issue and returns its key (e.g. PLAT-12).
+func (c *Client) CreateIssue(ctx context.Context, is NewIssue) (string, error) {
+	var f issueFields
+	f.Project.Key = is.Project
+	f.IssueType = named{Name: is.IssueType}
+	f.Summary = is.Summary
+	if r := []rune(f.Summary); len(r) > MaxSummary {
+		f.Summary = string(r[:MaxSummary-1]) + "…"
+	}
+	f.Description = is.Description
+	if is.Priority != "" {
+		f.Priority = &named{Name: is.Priority}
+	}
+	f.Labels = is.Labels
+
+	var out struct {
+		Key string `json:"key"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/rest/api/2/issue", map[string]any{"fields": f}, &out); err != nil {
+		return "", fmt.Errorf("create jira issue: %w", err)
+	}
+	return out.Key, nil
+}
+
+// BrowseURL is the web page of issue key.
+func (c *Client) BrowseURL(key string) string {
+	return c.base + "/browse/" + key
+}
+
+// Myself returns the display name of the user behind the token.
+func (c *Client) Myself(ctx context.Context) (string, error) {
+	var me struct {
+		DisplayName  string `json:"displayName"`
+		EmailAddress string `json:"emailAddress"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/rest/api/2/myself", nil, &me); err != nil {
+		return "", err
+	}
+	if me.EmailAddress != "" {
+		return me.DisplayName + " <" + me.EmailAddress + ">", nil
+	}
+	return me.DisplayName, nil
+}
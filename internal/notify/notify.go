@@ -0,0 +1,205 @@
+// Package notify posts short triage summaries to chat webhooks (Slack,
+// Microsoft Teams, Mattermost) and to generic JSON webhooks.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Sink types.
+const (
+	Slack      = "slack"
+	Teams      = "teams"
+	Mattermost = "mattermost"
+	Webhook    = "webhook"
+)
+
+const requestTimeout = 10 * time.Second
+
+// Summary is what a sink receives: the findings that passed its filter.
+type Summary struct {
+	Repo     string         `json:"repo"`
+	Commit   string         `json:"commit,omitempty"`
+	Scope    string         `json:"scope,omitempty"`
+	URL      string         `json:"url,omitempty"` // issue or other published report
+	Counts   map[string]int `json:"counts"`        // per severity
+	Total    int            `json:"total"`
+	Findings []Item         `json:"findings"` // the top ones
+}
+
+type Item struct {
+	Type     string `json:"type"`
+	Severity string `json:"severity,omitempty"`
+	Title    string `json:"title"`
+	File     string `json:"file"`
+	Lines    string `json:"lines,omitempty"`
+}
+
+// Sink is one configured notification target.
+type Sink struct {
+	Type   string
+	URL    string
+	Secret string // webhook only: HMAC-SHA256 key
+}
+
+// Send posts s to the sink.
+func Send(ctx context.Context, sink Sink, s Summary) error {
+	var payload any
+	switch sink.Type {
+	case Slack:
+		payload = map[string]string{"text": slackText(s)}
+	case Mattermost:
+		payload = map[string]string{"text": markdownText(s), "username": "DAI"}
+	case Teams:
+		payload = teamsCard(s)
+	case Webhook:
+		payload = map[string]any{"event": "triage", "summary": s}
+	default:
+		return fmt.Errorf("unknown notification type %q (use slack, teams, mattermost or webhook)", sink.Type)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sink.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dai-cli/triage")
+	if sink.Type == Webhook && sink.Secret != "" {
+		req.Header.Set("X-Dai-Signature-256", Sign(sink.Secret, body))
+	}
+	resp, err := (&http.Client{Timeout: requestTimeout}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s webhook: %d: %s", sink.Type, resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	return nil
+}
+
+// Sign returns the X-Dai-Signature-256 header value for body:
+// "sha256=" and the hex HMAC-SHA256 of body under secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// headline is the first line of every message.
+func headline(s Summary) string {
+	var parts []string
+	for _, sev := range []string{"high", "medium", "low"} {
+		if n := s.Counts[sev]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, sev))
+		}
+	}
+	h := fmt.Sprintf("DAI triage: %d finding(s) in %s", s.Total, s.Repo)
+	if len(parts) > 0 {
+		h += " (" + strings.Join(parts, ", ") + ")"
+	}
+	return h
+}
+
+func itemText(it Item) string {
+	sev := ""
+	if it.Severity != "" {
+		sev = strings.ToUpper(it.Severity) + " "
+	}
+	loc := it.File
+	if it.Lines != "" {
+		loc += ":" + it.Lines
+	}
+	return fmt.Sprintf("%s%s: %s (%s)", sev, it.Type, it.Title, loc)
+}
+
+func more(s Summary) int {
+	return s.Total - len(s.Findings)
+}
+
+func slackText(s Summary) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%s*\n", slackEscape(headline(s)))
+	if s.Scope != "" {
+		fmt.Fprintf(&sb, "_%s_\n", slackEscape(s.Scope))
+	}
+	for _, it := range s.Findings {
+		fmt.Fprintf(&sb, "• %s\n", slackEscape(itemText(it)))
+	}
+	if n := more(s); n > 0 {
+		fmt.Fprintf(&sb, "…and %d more\n", n)
+	}
+	if s.URL != "" {
+		fmt.Fprintf(&sb, "<%s|Open report>\n", s.URL)
+	}
+	return sb.String()
+}
+
+// slackEscape escapes the characters Slack treats as control sequences.
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+func markdownText(s Summary) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**%s**\n", headline(s))
+	if s.Scope != "" {
+		fmt.Fprintf(&sb, "_%s_\n", s.Scope)
+	}
+	for _, it := range s.Findings {
+		fmt.Fprintf(&sb, "- %s\n", itemText(it))
+	}
+	if n := more(s); n > 0 {
+		fmt.Fprintf(&sb, "…and %d more\n", n)
+	}
+	if s.URL != "" {
+		fmt.Fprintf(&sb, "[Open report](%s)\n", s.URL)
+	}
+	return sb.String()
+}
+
+// teamsCard wraps an Adaptive Card the way Teams incoming webhooks and
+// Workflows expect it.
+func teamsCard(s Summary) map[string]any {
+	body := []map[string]any{
+		{"type": "TextBlock", "text": headline(s), "weight": "Bolder", "wrap": true},
+	}
+	if s.Scope != "" {
+		body = append(body, map[string]any{"type": "TextBlock", "text": s.Scope, "isSubtle": true, "wrap": true})
+	}
+	for _, it := range s.Findings {
+		body = append(body, map[string]any{"type": "TextBlock", "text": "- " + itemText(it), "wrap": true, "spacing": "Small"})
+	}
+	if n := more(s); n > 0 {
+		body = append(body, map[string]any{"type": "TextBlock", "text": fmt.Sprintf("…and %d more", n), "isSubtle": true})
+	}
+	card := map[string]any{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+	if s.URL != "" {
+		card["actions"] = []map[string]any{{"type": "Action.OpenUrl", "title": "Open report", "url": s.URL}}
+	}
+	return map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{
+			{"contentType": "application/vnd.microsoft.card.adaptive", "content": card},
+		},
+	}
+}
@@ -0,0 +1,61 @@
+package project
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Authors maps commit author emails to GitHub logins (.dai/authors.yaml):
+//
+//	emails:
+//	  jane@example.com: janedoe
+type Authors struct {
+	Emails map[string]string `yaml:"emails"`
+}
+
+func AuthorsPath(root string) string {
+	return filepath.Join(root, ".dai", "authors.yaml")
+}
+
+// LoadAuthors reads .dai/authors.yaml; a missing file yields an empty map.
+func LoadAuthors(root string) (*Authors, error) {
+	b, err := os.ReadFile(AuthorsPath(root))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &Authors{}, nil
+		}
+		return nil, err
+	}
+	var a Authors
+	if err := yaml.Unmarshal(b, &a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+var reNoreply = regexp.MustCompile(`^(?:\d+\+)?([A-Za-z0-9-]+)@users\.noreply\.github\.com$`)
+
+// Login resolves an email to a GitHub login, first through the mapping and
+// then through GitHub's noreply address format. It returns "" if unknown.
+func (a *Authors) Login(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return ""
+	}
+	if a != nil {
+		for k, v := range a.Emails {
+			if strings.ToLower(strings.TrimSpace(k)) == email {
+				return strings.TrimPrefix(strings.TrimSpace(v), "@")
+			}
+		}
+	}
+	if m := reNoreply.FindStringSubmatch(email); m != nil {
+		return m[1]
+	}
+	return ""
+}
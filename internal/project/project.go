@@ -8,9 +8,67 @@ import (
 )
 
 type Project struct {
-	Provider string `yaml:"provider"` // "github"
-	Owner    string `yaml:"owner"`
+	Provider string `yaml:"provider"`       // "github" | "gitlab"
+	Host     string `yaml:"host,omitempty"` // e.g. github.com or a self-hosted server
+	Owner    string `yaml:"owner"`          // namespace; may contain "/" (GitLab subgroups)
 	Repo     string `yaml:"repo"`
+	// APIURL overrides the API root derived from Host (e.g. a GHES proxy).
+	APIURL string `yaml:"api_url,omitempty"`
+
+	Hooks *Hooks `yaml:"hooks,omitempty"`
+	Jira  *Jira  `yaml:"jira,omitempty"`
+
+	Notify []Notify `yaml:"notify,omitempty"`
+
+	Validate *Validate `yaml:"validate,omitempty"`
+}
+
+// Hooks configures the git hooks installed by `dai hook install`.
+type Hooks struct {
+	FailOn string   `yaml:"fail_on,omitempty"` // low|medium|high (default high)
+	Ext    []string `yaml:"ext,omitempty"`     // extensions to analyze
+}
+
+// Jira configures `dai triage --publish jira`.
+type Jira struct {
+	URL       string `yaml:"url"`                  // site, e.g. https://acme.atlassian.net
+	Project   string `yaml:"project"`              // project key
+	IssueType string `yaml:"issue_type,omitempty"` // default Bug
+	Per       string `yaml:"per,omitempty"`        // run (default) | finding
+	// Priorities maps a severity (low|medium|high) to a Jira priority name;
+	// unmapped severities use the project default.
+	Priorities map[string]string `yaml:"priorities,omitempty"`
+	Labels     []string          `yaml:"labels,omitempty"`
+}
+
+// Notify is a notification sink for `dai triage`. URL and Secret expand
+// ${DAI_NOTIFY_*} references so webhooks can stay out of the file; other
+// variables are not expanded, since the file is part of the repository.
+type Notify struct {
+	Type        string   `yaml:"type"` // slack | teams | mattermost | webhook
+	Name        string   `yaml:"name,omitempty"`
+	URL         string   `yaml:"url"`
+	Secret      string   `yaml:"secret,omitempty"`       // webhook: HMAC-SHA256 key
+	MinSeverity string   `yaml:"min_severity,omitempty"` // low (default) | medium | high
+	Types       []string `yaml:"types,omitempty"`        // bug, enhancement (default both)
+}
+
+// Validate is the check a generated fix must pass, in a throwaway worktree,
+// before dai proposes it.
+type Validate struct {
+	Command string `yaml:"command"`           // run with sh -c, e.g. "go build ./... && go test ./..."
+	Timeout string `yaml:"timeout,omitempty"` // Go duration (default 10m)
+}
+
+// DefaultHost is assumed for project files written before Host existed.
+const DefaultHost = "github.com"
+
+// APIHost returns Host, or DefaultHost when unset.
+func (p *Project) APIHost() string {
+	if p.Host == "" {
+		return DefaultHost
+	}
+	return p.Host
 }
 
 func Path(root string) string {
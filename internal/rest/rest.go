@@ -0,0 +1,251 @@
+// Package rest is the HTTP plumbing shared by the JSON API clients of the
+// git hosts: retries on rate limits, Link-header paging and a typed error.
+// The backends only add their endpoints and how they authenticate.
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	requestTimeout = 30 * time.Second
+	// maxRateLimitWait bounds how long a call sleeps for a rate limit reset
+	// before giving up.
+	maxRateLimitWait = 90 * time.Second
+	maxRetries       = 3
+)
+
+// sharedTransport keeps connections alive across clients and calls.
+var sharedTransport = http.DefaultTransport.(*http.Transport).Clone()
+
+// StatusError is a sentinel that matches, with errors.Is, every *APIError
+// of its status code. Backends declare theirs with their own wording.
+type StatusError struct {
+	Code int
+	Msg  string
+}
+
+func (e *StatusError) Error() string { return e.Msg }
+
+// Status returns a sentinel for the HTTP status code.
+func Status(code int, msg string) error {
+	return &StatusError{Code: code, Msg: msg}
+}
+
+// APIError is a non-2xx response from the API.
+type APIError struct {
+	Service    string // "github", "gitlab", "gitea"
+	StatusCode int
+	Method     string
+	URL        string
+	Message    string
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = strings.TrimSpace(e.Body)
+	}
+	return fmt.Sprintf("%s %s %s: %d: %s", e.Service, e.Method, e.URL, e.StatusCode, msg)
+}
+
+func (e *APIError) Is(target error) bool {
+	s, ok := target.(*StatusError)
+	return ok && s.Code == e.StatusCode
+}
+
+// Client sends JSON requests to an API root.
+type Client struct {
+	Service string
+	Base    string
+
+	// Auth sets the credentials of each request.
+	Auth func(ctx context.Context, h http.Header) error
+	// Header is sent with every request; Accept defaults to JSON.
+	Header http.Header
+	// ErrorMessage extracts the message of an error body; the default
+	// reads {"message": "..."}.
+	ErrorMessage func(raw []byte) string
+	// RateLimited reports whether a response hit a rate limit and how long
+	// to wait before retrying. A non-nil err is returned instead of the
+	// *APIError when the wait is too long or the retries run out. The
+	// default retries 429s after Retry-After (or a minute).
+	RateLimited func(resp *http.Response, body []byte) (wait time.Duration, limited bool, err error)
+	// Unauthorized is called once when a request gets a 401; returning
+	// true sends it again, e.g. with a refreshed token.
+	Unauthorized func() bool
+
+	hc *http.Client
+}
+
+// New creates a client for an API root. auth may be nil.
+func New(service, base string, auth func(ctx context.Context, h http.Header) error) *Client {
+	return &Client{
+		Service: service,
+		Base:    strings.TrimRight(base, "/"),
+		Auth:    auth,
+		hc:      &http.Client{Transport: sharedTransport, Timeout: requestTimeout},
+	}
+}
+
+// Do sends one request, retrying on rate limits, and decodes a JSON
+// response into out (when non-nil). path may be absolute (pagination links).
+func (c *Client) Do(ctx context.Context, method, path string, in, out any) (*http.Response, error) {
+	var payload []byte
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		payload = b
+	}
+	u := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		u = c.Base + path
+	}
+
+	reauthed := false
+	for attempt := 0; ; attempt++ {
+		var body io.Reader
+		if payload != nil {
+			body = bytes.NewReader(payload)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, u, body)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		for k, v := range c.Header {
+			req.Header[k] = v
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "dai-cli/triage")
+		if c.Auth != nil {
+			if err := c.Auth(ctx, req.Header); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := c.hc.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			defer resp.Body.Close()
+			if out != nil && resp.StatusCode != http.StatusNoContent {
+				if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+					return resp, err
+				}
+			}
+			return resp, nil
+		}
+
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusUnauthorized && c.Unauthorized != nil && !reauthed {
+			reauthed = true
+			if c.Unauthorized() {
+				continue
+			}
+		}
+
+		apiErr := &APIError{Service: c.Service, StatusCode: resp.StatusCode, Method: method, URL: u, Body: string(raw)}
+		msg := errorMessage
+		if c.ErrorMessage != nil {
+			msg = c.ErrorMessage
+		}
+		apiErr.Message = msg(raw)
+
+		limited := retryAfter
+		if c.RateLimited != nil {
+			limited = c.RateLimited
+		}
+		if wait, ok, rlErr := limited(resp, raw); ok {
+			if attempt >= maxRetries || wait > maxRateLimitWait {
+				if rlErr != nil {
+					return resp, rlErr
+				}
+				return resp, apiErr
+			}
+			select {
+			case <-ctx.Done():
+				return resp, ctx.Err()
+			case <-time.After(wait):
+			}
+			continue
+		}
+		return resp, apiErr
+	}
+}
+
+// errorMessage reads {"message": "..."}.
+func errorMessage(raw []byte) string {
+	var body struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(raw, &body) != nil {
+		return ""
+	}
+	return body.Message
+}
+
+// retryAfter waits for Retry-After, or a minute, on 429s.
+func retryAfter(resp *http.Response, _ []byte) (time.Duration, bool, error) {
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false, nil
+	}
+	wait := time.Minute
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		wait = time.Duration(secs) * time.Second
+	}
+	return wait, true, nil
+}
+
+// ErrStopPaging, returned by a GetPages callback, ends the listing early
+// without failing it.
+var ErrStopPaging = errors.New("stop paging")
+
+var reNextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// GetPages follows Link: rel="next" headers and hands every page to fn.
+func GetPages[T any](ctx context.Context, c *Client, path string, fn func([]T) error) error {
+	next := path
+	for next != "" {
+		var page []T
+		resp, err := c.Do(ctx, http.MethodGet, next, nil, &page)
+		if err != nil {
+			return err
+		}
+		if err := fn(page); errors.Is(err, ErrStopPaging) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		next = ""
+		if m := reNextLink.FindStringSubmatch(resp.Header.Get("Link")); m != nil && len(page) > 0 {
+			next = m[1]
+		}
+	}
+	return nil
+}
+
+// NewPull is the body of a "create pull request" call, which GitHub and
+// Gitea share.
+type NewPull struct {
+	Title string `json:"title"`
+	Body  string `json:"body,omitempty"`
+	Head  string `json:"head"`
+	Base  string `json:"base"`
+}
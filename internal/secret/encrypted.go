@@ -0,0 +1,213 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// scrypt parameters for new files; existing files carry their own.
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+	keyLen  = 32
+)
+
+// PassphraseEnv unlocks the encrypted file without a prompt (CI, hooks).
+const PassphraseEnv = "DAI_PASSPHRASE"
+
+// Passphrase asks for the passphrase of the encrypted file; create is set
+// when the file does not exist yet. The default only reads PassphraseEnv.
+var Passphrase = func(create bool) (string, error) {
+	if p := os.Getenv(PassphraseEnv); p != "" {
+		return p, nil
+	}
+	return "", fmt.Errorf("encrypted secrets are locked — set %s", PassphraseEnv)
+}
+
+// sealedFile is the on-disk format: the name → secret map as JSON, sealed
+// with AES-256-GCM under a key derived from the passphrase.
+type sealedFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	N       int    `json:"n"`
+	R       int    `json:"r"`
+	P       int    `json:"p"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// vault is the unlocked file, kept for the rest of the process so the
+// passphrase is asked at most once.
+var vault struct {
+	sync.Mutex
+	open    bool
+	key     []byte
+	sealed  sealedFile
+	secrets map[string]string
+}
+
+func encryptedPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".dai", "secrets.enc"), nil
+}
+
+// unlock reads and decrypts the file, or prepares a new one. The caller
+// holds vault's lock.
+func unlock() error {
+	if vault.open {
+		return nil
+	}
+	p, err := encryptedPath()
+	if err != nil {
+		return err
+	}
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		pass, err := Passphrase(true)
+		if err != nil {
+			return err
+		}
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+		f := sealedFile{Version: 1, Salt: salt, N: scryptN, R: scryptR, P: scryptP}
+		key, err := scrypt.Key([]byte(pass), salt, f.N, f.R, f.P, keyLen)
+		if err != nil {
+			return err
+		}
+		vault.key, vault.sealed, vault.secrets, vault.open = key, f, map[string]string{}, true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var f sealedFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return fmt.Errorf("read %s: %w", p, err)
+	}
+	if f.Version != 1 {
+		return fmt.Errorf("%s: unsupported version %d", p, f.Version)
+	}
+	pass, err := Passphrase(false)
+	if err != nil {
+		return err
+	}
+	key, err := scrypt.Key([]byte(pass), f.Salt, f.N, f.R, f.P, keyLen)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	plain, err := gcm.Open(nil, f.Nonce, f.Data, nil)
+	if err != nil {
+		return errors.New("wrong passphrase or corrupted secrets file")
+	}
+	secrets := map[string]string{}
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return fmt.Errorf("read %s: %w", p, err)
+	}
+	vault.key, vault.sealed, vault.secrets, vault.open = key, f, secrets, true
+	return nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal writes the unlocked secrets back with a fresh nonce.
+func seal() error {
+	plain, err := json.Marshal(vault.secrets)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(vault.key)
+	if err != nil {
+		return err
+	}
+	f := vault.sealed
+	f.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return err
+	}
+	f.Data = gcm.Seal(nil, f.Nonce, plain, nil)
+	b, err := json.Marshal(&f)
+	if err != nil {
+		return err
+	}
+	p, err := encryptedPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return err
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		return err
+	}
+	vault.sealed = f
+	return nil
+}
+
+func encryptedGet(name string) (string, error) {
+	vault.Lock()
+	defer vault.Unlock()
+	if err := unlock(); err != nil {
+		return "", err
+	}
+	s, ok := vault.secrets[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return s, nil
+}
+
+func encryptedSet(name, secret string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("empty secret name")
+	}
+	vault.Lock()
+	defer vault.Unlock()
+	if err := unlock(); err != nil {
+		return err
+	}
+	vault.secrets[name] = secret
+	return seal()
+}
+
+func encryptedDelete(name string) error {
+	vault.Lock()
+	defer vault.Unlock()
+	if err := unlock(); err != nil {
+		return err
+	}
+	if _, ok := vault.secrets[name]; !ok {
+		return ErrNotFound
+	}
+	delete(vault.secrets, name)
+	return seal()
+}
@@ -0,0 +1,119 @@
+// Package secret keeps credentials out of dai's plaintext config files.
+// A stored value is either the secret itself (plaintext) or a reference
+// to it in a backend:
+//
+//	encrypted:<name>       ~/.dai/secrets.enc, scrypt + AES-256-GCM
+//	secret-service:<name>  the freedesktop Secret Service (GNOME Keyring, KWallet)
+//	exec:<command>         the output of a command, e.g. "exec:pass show dai/openai"
+package secret
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Backends new secrets can be written to; exec references are read-only
+// and written by hand.
+const (
+	Plaintext     = "plaintext"
+	Encrypted     = "encrypted"
+	SecretService = "secret-service"
+	Exec          = "exec"
+)
+
+// ErrNotFound means a reference points to a secret the backend does not
+// have.
+var ErrNotFound = errors.New("secret not found")
+
+// Backends lists the backends Put accepts.
+func Backends() []string {
+	return []string{Plaintext, Encrypted, SecretService}
+}
+
+// Valid reports whether backend is one of Backends ("" means plaintext).
+func Valid(backend string) bool {
+	switch backend {
+	case "", Plaintext, Encrypted, SecretService:
+		return true
+	}
+	return false
+}
+
+// parse splits a stored value into backend and name; plaintext values
+// return Plaintext and the value itself.
+func parse(value string) (backend, name string) {
+	for _, b := range []string{Encrypted, SecretService, Exec} {
+		if rest, ok := strings.CutPrefix(value, b+":"); ok {
+			return b, strings.TrimSpace(rest)
+		}
+	}
+	return Plaintext, value
+}
+
+// Backend returns the backend value is kept in.
+func Backend(value string) string {
+	b, _ := parse(value)
+	return b
+}
+
+// Resolve returns the secret value stands for.
+func Resolve(ctx context.Context, value string) (string, error) {
+	backend, name := parse(value)
+	var (
+		s   string
+		err error
+	)
+	switch backend {
+	case Plaintext:
+		return value, nil
+	case Encrypted:
+		s, err = encryptedGet(name)
+	case SecretService:
+		s, err = secretServiceGet(ctx, name)
+	case Exec:
+		s, err = execGet(ctx, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s:%s: %w", backend, name, err)
+	}
+	return s, nil
+}
+
+// Put stores secret under name in backend and returns the value to write
+// in its place: the secret itself for plaintext, a reference otherwise.
+func Put(ctx context.Context, backend, name, secret string) (string, error) {
+	switch backend {
+	case "", Plaintext:
+		return secret, nil
+	case Encrypted:
+		if err := encryptedSet(name, secret); err != nil {
+			return "", err
+		}
+	case SecretService:
+		if err := secretServiceSet(ctx, name, secret); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("cannot store secrets in %q (use %s)", backend, strings.Join(Backends(), ", "))
+	}
+	return backend + ":" + name, nil
+}
+
+// Remove deletes the secret a reference points to. Plaintext values and
+// exec references have nothing to delete.
+func Remove(ctx context.Context, value string) error {
+	backend, name := parse(value)
+	var err error
+	switch backend {
+	case Encrypted:
+		err = encryptedDelete(name)
+	case SecretService:
+		err = secretServiceDelete(ctx, name)
+	}
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
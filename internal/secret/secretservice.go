@@ -0,0 +1,104 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// commandTimeout bounds the helper commands; unlocking a keyring or a
+// password manager may wait for the user.
+const commandTimeout = 2 * time.Minute
+
+// SecretServiceAvailable reports whether the freedesktop Secret Service
+// can be reached: a D-Bus session and libsecret's secret-tool.
+func SecretServiceAvailable() bool {
+	if runtime.GOOS != "linux" && runtime.GOOS != "freebsd" {
+		return false
+	}
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		return false
+	}
+	_, err := exec.LookPath("secret-tool")
+	return err == nil
+}
+
+// secretTool runs secret-tool with the attributes identifying name.
+func secretTool(ctx context.Context, stdin, op, name string, extra ...string) (string, error) {
+	if !SecretServiceAvailable() {
+		return "", errors.New("Secret Service not available (needs a D-Bus session and secret-tool from libsecret)")
+	}
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+	args := append([]string{op}, extra...)
+	args = append(args, "service", "dai", "name", name)
+	cmd := exec.CommandContext(ctx, "secret-tool", args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	msg := strings.TrimSpace(stderr.String())
+	var exitErr *exec.ExitError
+	if op == "lookup" && msg == "" && (len(out) == 0 || errors.As(err, &exitErr)) {
+		// lookup exits 1 without output when nothing matches
+		return "", ErrNotFound
+	}
+	if err != nil {
+		if msg != "" {
+			return "", fmt.Errorf("secret-tool %s: %s", op, msg)
+		}
+		return "", fmt.Errorf("secret-tool %s: %w", op, err)
+	}
+	return string(out), nil
+}
+
+func secretServiceGet(ctx context.Context, name string) (string, error) {
+	out, err := secretTool(ctx, "", "lookup", name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(out, "\n"), nil
+}
+
+func secretServiceSet(ctx context.Context, name, secret string) error {
+	_, err := secretTool(ctx, secret, "store", name, "--label=dai "+name)
+	return err
+}
+
+func secretServiceDelete(ctx context.Context, name string) error {
+	_, err := secretTool(ctx, "", "clear", name)
+	return err
+}
+
+// execGet runs command through the shell and returns its trimmed output.
+func execGet(ctx context.Context, command string) (string, error) {
+	if command == "" {
+		return "", errors.New("empty command")
+	}
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	// the password manager may need to prompt on the terminal
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	s := strings.TrimSpace(string(out))
+	if s == "" {
+		return "", ErrNotFound
+	}
+	return s, nil
+}
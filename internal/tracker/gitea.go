@@ -0,0 +1,201 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gorankrgovic/dai/internal/gitea"
+	"github.com/gorankrgovic/dai/internal/rest"
+)
+
+// giteaTracker serves Gitea and Forgejo, which share the API.
+type giteaTracker struct {
+	c           *gitea.Client
+	owner, repo string
+	labelIDs    map[string]int64 // filled by EnsureLabels
+}
+
+func newGitea(cfg Config) *giteaTracker {
+	return &giteaTracker{c: gitea.NewClient(cfg.APIBase, cfg.Token), owner: cfg.Namespace, repo: cfg.Repo}
+}
+
+func (t *giteaTracker) Provider() string { return Gitea }
+
+func (t *giteaTracker) EnsureLabels(ctx context.Context, labels []string) error {
+	ids, err := t.c.EnsureLabels(ctx, t.owner, t.repo, labels, LabelColor)
+	if err != nil {
+		return err
+	}
+	if t.labelIDs == nil {
+		t.labelIDs = map[string]int64{}
+	}
+	for i, name := range labels {
+		t.labelIDs[name] = ids[i]
+	}
+	return nil
+}
+
+// resolveLabels returns the IDs of labels, which the API takes instead of
+// names. Labels EnsureLabels has not seen are looked up and created.
+func (t *giteaTracker) resolveLabels(ctx context.Context, labels []string) ([]int64, error) {
+	var missing []string
+	for _, name := range labels {
+		if _, ok := t.labelIDs[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		if err := t.EnsureLabels(ctx, missing); err != nil {
+			return nil, err
+		}
+	}
+	ids := make([]int64, len(labels))
+	for i, name := range labels {
+		ids[i] = t.labelIDs[name]
+	}
+	return ids, nil
+}
+
+// CreateIssue resolves label names to IDs, since the API only takes IDs.
+func (t *giteaTracker) CreateIssue(ctx context.Context, is NewIssue) (*Issue, error) {
+	ids, err := t.resolveLabels(ctx, is.Labels)
+	if err != nil {
+		return nil, err
+	}
+	out, err := t.c.CreateIssue(ctx, t.owner, t.repo, is.Title, is.Body, ids, is.Assignees)
+	if err != nil {
+		return nil, err
+	}
+	return &Issue{Number: out.Number, Title: out.Title, Body: out.Body, URL: out.HTMLURL}, nil
+}
+
+func (t *giteaTracker) Issue(ctx context.Context, number int) (*Issue, error) {
+	is, err := t.c.GetIssue(ctx, t.owner, t.repo, number)
+	if err != nil {
+		return nil, err
+	}
+	return &Issue{Number: is.Number, Title: is.Title, Body: is.Body, URL: is.HTMLURL, Closed: is.State == "closed"}, nil
+}
+
+func (t *giteaTracker) ListOpenIssues(ctx context.Context, fn func(Issue) bool) error {
+	return t.c.ListIssues(ctx, t.owner, t.repo, "open", func(is gitea.Issue) bool {
+		return fn(Issue{Number: is.Number, Title: is.Title, Body: is.Body, URL: is.HTMLURL})
+	})
+}
+
+func (t *giteaTracker) SetIssueBody(ctx context.Context, number int, body string) error {
+	return t.c.UpdateIssue(ctx, t.owner, t.repo, number, gitea.IssueUpdate{Body: &body})
+}
+
+func (t *giteaTracker) CloseIssue(ctx context.Context, number int) error {
+	state := "closed"
+	return t.c.UpdateIssue(ctx, t.owner, t.repo, number, gitea.IssueUpdate{State: &state})
+}
+
+func (t *giteaTracker) AddLabels(ctx context.Context, number int, labels []string) error {
+	ids, err := t.resolveLabels(ctx, labels)
+	if err != nil {
+		return err
+	}
+	return t.c.AddLabels(ctx, t.owner, t.repo, number, ids)
+}
+
+func (t *giteaTracker) Comment(ctx context.Context, number int, body string) (string, error) {
+	c, err := t.c.CreateComment(ctx, t.owner, t.repo, number, body)
+	if err != nil {
+		return "", err
+	}
+	return c.HTMLURL, nil
+}
+
+func (t *giteaTracker) ChangeRequest(ctx context.Context, number int) (*ChangeRequest, error) {
+	pr, err := t.c.GetPullRequest(ctx, t.owner, t.repo, number)
+	if err != nil {
+		return nil, err
+	}
+	return &ChangeRequest{
+		Number:   pr.Number,
+		URL:      pr.HTMLURL,
+		HeadRef:  pr.Head.Ref,
+		BaseRef:  pr.Base.Ref,
+		HeadSHA:  pr.Head.SHA,
+		BaseSHA:  pr.Base.SHA,
+		FetchRef: fmt.Sprintf("refs/pull/%d/head", pr.Number),
+	}, nil
+}
+
+func (t *giteaTracker) OpenChangeRequest(ctx context.Context, cr NewChangeRequest) (*ChangeRequest, error) {
+	pr, err := t.c.CreatePullRequest(ctx, t.owner, t.repo, rest.NewPull{Title: cr.Title, Body: cr.Body, Head: cr.Head, Base: cr.Base})
+	if err != nil {
+		return nil, err
+	}
+	return &ChangeRequest{Number: pr.Number, URL: pr.HTMLURL, HeadRef: pr.Head.Ref, BaseRef: pr.Base.Ref, HeadSHA: pr.Head.SHA, BaseSHA: pr.Base.SHA}, nil
+}
+
+// Notes returns the review comments only: the API can neither edit review
+// summaries nor resolve conversations, so older summaries stay as they are
+// and notes carry no ThreadID.
+func (t *giteaTracker) Notes(ctx context.Context, cr *ChangeRequest) ([]Note, error) {
+	reviews, err := t.c.ListReviews(ctx, t.owner, t.repo, cr.Number)
+	if err != nil {
+		return nil, err
+	}
+	var out []Note
+	for _, r := range reviews {
+		if r.Comments == 0 {
+			continue
+		}
+		comments, err := t.c.ListReviewComments(ctx, t.owner, t.repo, cr.Number, r.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range comments {
+			line := c.Position
+			if line == 0 {
+				line = c.OriginalPosition
+			}
+			out = append(out, Note{
+				ID:       strconv.FormatInt(c.ID, 10),
+				Body:     c.Body,
+				Resolved: c.Resolver != nil,
+				Line:     max(line, 1),
+			})
+		}
+	}
+	return out, nil
+}
+
+func (t *giteaTracker) EditNote(ctx context.Context, cr *ChangeRequest, n Note, body string) error {
+	id, err := strconv.ParseInt(n.ID, 10, 64)
+	if err != nil {
+		return err
+	}
+	return t.c.EditComment(ctx, t.owner, t.repo, id, body)
+}
+
+// SetResolved is a no-op; resolving conversations is not in the API.
+func (t *giteaTracker) SetResolved(ctx context.Context, cr *ChangeRequest, n Note, resolved bool) error {
+	return nil
+}
+
+// SubmitReview posts everything as one review, or only the summary when
+// the server refuses a comment.
+func (t *giteaTracker) SubmitReview(ctx context.Context, cr *ChangeRequest, comments []LineComment, summary func([]LineComment) string) (string, error) {
+	drafts := make([]gitea.DraftComment, 0, len(comments))
+	for _, c := range comments {
+		drafts = append(drafts, gitea.DraftComment{Path: c.Path, Body: c.Body, NewPosition: c.Line})
+	}
+	review, err := t.c.CreateReview(ctx, t.owner, t.repo, cr.Number, cr.HeadSHA, summary(nil), drafts)
+	if errors.Is(err, gitea.ErrValidation) && len(drafts) > 0 {
+		review, err = t.c.CreateReview(ctx, t.owner, t.repo, cr.Number, cr.HeadSHA, summary(comments), nil)
+	}
+	if err != nil {
+		return "", err
+	}
+	if review.HTMLURL == "" {
+		return cr.URL, nil
+	}
+	return review.HTMLURL, nil
+}
@@ -0,0 +1,180 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gorankrgovic/dai/internal/gh"
+	"github.com/gorankrgovic/dai/internal/rest"
+)
+
+type githubTracker struct {
+	c           *gh.Client
+	owner, repo string
+}
+
+func newGitHub(cfg Config) *githubTracker {
+	c := gh.NewClient(cfg.APIBase, cfg.Token)
+	if cfg.GitHubTokens != nil {
+		c = gh.NewClientWithTokenSource(cfg.APIBase, cfg.GitHubTokens)
+	}
+	return &githubTracker{c: c, owner: cfg.Namespace, repo: cfg.Repo}
+}
+
+func (t *githubTracker) Provider() string { return GitHub }
+
+func (t *githubTracker) EnsureLabels(ctx context.Context, labels []string) error {
+	return t.c.EnsureLabels(ctx, t.owner, t.repo, labels, LabelColor)
+}
+
+func (t *githubTracker) CreateIssue(ctx context.Context, is NewIssue) (*Issue, error) {
+	url, num, err := t.c.CreateIssue(ctx, t.owner, t.repo, is.Title, is.Body, is.Labels, is.Assignees)
+	if err != nil {
+		return nil, err
+	}
+	return &Issue{Number: num, Title: is.Title, Body: is.Body, URL: url}, nil
+}
+
+func (t *githubTracker) Issue(ctx context.Context, number int) (*Issue, error) {
+	is, err := t.c.GetIssue(ctx, t.owner, t.repo, number)
+	if err != nil {
+		return nil, err
+	}
+	if is.PullRequest != nil {
+		return nil, fmt.Errorf("#%d is a pull request", number)
+	}
+	return &Issue{Number: is.Number, Title: is.Title, Body: is.Body, URL: is.HTMLURL, Closed: is.State == "closed"}, nil
+}
+
+func (t *githubTracker) ListOpenIssues(ctx context.Context, fn func(Issue) bool) error {
+	return t.c.ListIssues(ctx, t.owner, t.repo, "open", func(is gh.Issue) bool {
+		return fn(Issue{Number: is.Number, Title: is.Title, Body: is.Body, URL: is.HTMLURL})
+	})
+}
+
+func (t *githubTracker) SetIssueBody(ctx context.Context, number int, body string) error {
+	return t.c.UpdateIssue(ctx, t.owner, t.repo, number, gh.IssueUpdate{Body: &body})
+}
+
+func (t *githubTracker) CloseIssue(ctx context.Context, number int) error {
+	state, reason := "closed", "completed"
+	return t.c.UpdateIssue(ctx, t.owner, t.repo, number, gh.IssueUpdate{State: &state, StateReason: &reason})
+}
+
+func (t *githubTracker) AddLabels(ctx context.Context, number int, labels []string) error {
+	return t.c.AddLabels(ctx, t.owner, t.repo, number, labels)
+}
+
+func (t *githubTracker) Comment(ctx context.Context, number int, body string) (string, error) {
+	return t.c.CreateComment(ctx, t.owner, t.repo, number, body)
+}
+
+func (t *githubTracker) ChangeRequest(ctx context.Context, number int) (*ChangeRequest, error) {
+	pr, err := t.c.GetPullRequest(ctx, t.owner, t.repo, number)
+	if err != nil {
+		return nil, err
+	}
+	return &ChangeRequest{
+		Number:   pr.Number,
+		URL:      pr.HTMLURL,
+		HeadRef:  pr.Head.Ref,
+		BaseRef:  pr.Base.Ref,
+		HeadSHA:  pr.Head.SHA,
+		BaseSHA:  pr.Base.SHA,
+		FetchRef: fmt.Sprintf("refs/pull/%d/head", pr.Number),
+	}, nil
+}
+
+func (t *githubTracker) OpenChangeRequest(ctx context.Context, cr NewChangeRequest) (*ChangeRequest, error) {
+	pr, err := t.c.CreatePullRequest(ctx, t.owner, t.repo, rest.NewPull{Title: cr.Title, Body: cr.Body, Head: cr.Head, Base: cr.Base})
+	if err != nil {
+		return nil, err
+	}
+	return &ChangeRequest{Number: pr.Number, URL: pr.HTMLURL, HeadRef: pr.Head.Ref, BaseRef: pr.Base.Ref, HeadSHA: pr.Head.SHA, BaseSHA: pr.Base.SHA}, nil
+}
+
+// Notes returns the top-level review comments with the state of their
+// thread, and the bodies of submitted reviews as summaries.
+func (t *githubTracker) Notes(ctx context.Context, cr *ChangeRequest) ([]Note, error) {
+	comments, err := t.c.ListReviewComments(ctx, t.owner, t.repo, cr.Number)
+	if err != nil {
+		return nil, err
+	}
+	threads, err := t.c.ListReviewThreads(ctx, t.owner, t.repo, cr.Number)
+	if err != nil {
+		return nil, err
+	}
+	byComment := make(map[int64]gh.ReviewThread, len(threads))
+	for _, th := range threads {
+		byComment[th.FirstCommentID] = th
+	}
+	var out []Note
+	for _, c := range comments {
+		if c.InReplyToID != 0 {
+			continue
+		}
+		th := byComment[c.ID]
+		out = append(out, Note{
+			ID:       strconv.FormatInt(c.ID, 10),
+			ThreadID: th.ID,
+			Body:     c.Body,
+			Resolved: th.Resolved,
+			Line:     max(c.Line, 1),
+		})
+	}
+	reviews, err := t.c.ListReviews(ctx, t.owner, t.repo, cr.Number)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range reviews {
+		if r.Body != "" {
+			out = append(out, Note{ID: strconv.FormatInt(r.ID, 10), Body: r.Body})
+		}
+	}
+	return out, nil
+}
+
+func (t *githubTracker) EditNote(ctx context.Context, cr *ChangeRequest, n Note, body string) error {
+	id, err := strconv.ParseInt(n.ID, 10, 64)
+	if err != nil {
+		return err
+	}
+	if n.Line == 0 {
+		return t.c.UpdateReview(ctx, t.owner, t.repo, cr.Number, id, body)
+	}
+	return t.c.UpdateReviewComment(ctx, t.owner, t.repo, id, body)
+}
+
+func (t *githubTracker) SetResolved(ctx context.Context, cr *ChangeRequest, n Note, resolved bool) error {
+	if n.ThreadID == "" {
+		return nil
+	}
+	if resolved {
+		return t.c.ResolveReviewThread(ctx, n.ThreadID)
+	}
+	return t.c.UnresolveReviewThread(ctx, n.ThreadID)
+}
+
+// SubmitReview posts everything as one review. GitHub rejects the whole
+// review when a single line is outside the diff; it is then resubmitted
+// with every comment moved into the summary.
+func (t *githubTracker) SubmitReview(ctx context.Context, cr *ChangeRequest, comments []LineComment, summary func([]LineComment) string) (string, error) {
+	drafts := make([]gh.DraftComment, 0, len(comments))
+	for _, c := range comments {
+		d := gh.DraftComment{Path: c.Path, Body: c.Body, Line: c.Line, Side: "RIGHT"}
+		if c.StartLine > 0 && c.StartLine < c.Line {
+			d.StartLine, d.StartSide = c.StartLine, "RIGHT"
+		}
+		drafts = append(drafts, d)
+	}
+	review, err := t.c.CreateReview(ctx, t.owner, t.repo, cr.Number, cr.HeadSHA, summary(nil), drafts)
+	if errors.Is(err, gh.ErrValidation) && len(drafts) > 0 {
+		review, err = t.c.CreateReview(ctx, t.owner, t.repo, cr.Number, cr.HeadSHA, summary(comments), nil)
+	}
+	if err != nil {
+		return "", err
+	}
+	return review.HTMLURL, nil
+}
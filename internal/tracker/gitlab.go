@@ -0,0 +1,175 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gorankrgovic/dai/internal/gitlab"
+)
+
+type gitlabTracker struct {
+	c               *gitlab.Client
+	namespace, repo string
+	web             string // project web URL
+}
+
+func newGitLab(cfg Config) *gitlabTracker {
+	host := strings.TrimSuffix(cfg.APIBase, "/api/v4")
+	return &gitlabTracker{
+		c:         gitlab.NewClient(cfg.APIBase, cfg.Token),
+		namespace: cfg.Namespace,
+		repo:      cfg.Repo,
+		web:       host + "/" + cfg.Namespace + "/" + cfg.Repo,
+	}
+}
+
+func (t *gitlabTracker) Provider() string { return GitLab }
+
+func (t *gitlabTracker) EnsureLabels(ctx context.Context, labels []string) error {
+	return t.c.EnsureLabels(ctx, t.namespace, t.repo, labels, LabelColor)
+}
+
+func (t *gitlabTracker) CreateIssue(ctx context.Context, is NewIssue) (*Issue, error) {
+	out, err := t.c.CreateIssue(ctx, t.namespace, t.repo, is.Title, is.Body, is.Labels, is.Assignees)
+	if err != nil {
+		return nil, err
+	}
+	return &Issue{Number: out.IID, Title: out.Title, Body: out.Description, URL: out.WebURL}, nil
+}
+
+func (t *gitlabTracker) Issue(ctx context.Context, number int) (*Issue, error) {
+	is, err := t.c.GetIssue(ctx, t.namespace, t.repo, number)
+	if err != nil {
+		return nil, err
+	}
+	return &Issue{Number: is.IID, Title: is.Title, Body: is.Description, URL: is.WebURL, Closed: is.State == "closed"}, nil
+}
+
+func (t *gitlabTracker) ListOpenIssues(ctx context.Context, fn func(Issue) bool) error {
+	return t.c.ListIssues(ctx, t.namespace, t.repo, "opened", func(is gitlab.Issue) bool {
+		return fn(Issue{Number: is.IID, Title: is.Title, Body: is.Description, URL: is.WebURL})
+	})
+}
+
+func (t *gitlabTracker) SetIssueBody(ctx context.Context, number int, body string) error {
+	return t.c.UpdateIssue(ctx, t.namespace, t.repo, number, gitlab.IssueUpdate{Description: &body})
+}
+
+func (t *gitlabTracker) CloseIssue(ctx context.Context, number int) error {
+	ev := "close"
+	return t.c.UpdateIssue(ctx, t.namespace, t.repo, number, gitlab.IssueUpdate{StateEvent: &ev})
+}
+
+func (t *gitlabTracker) AddLabels(ctx context.Context, number int, labels []string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	return t.c.UpdateIssue(ctx, t.namespace, t.repo, number, gitlab.IssueUpdate{AddLabels: strings.Join(labels, ",")})
+}
+
+func (t *gitlabTracker) Comment(ctx context.Context, number int, body string) (string, error) {
+	id, err := t.c.CreateIssueNote(ctx, t.namespace, t.repo, number, body)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/-/issues/%d#note_%d", t.web, number, id), nil
+}
+
+func (t *gitlabTracker) ChangeRequest(ctx context.Context, number int) (*ChangeRequest, error) {
+	mr, err := t.c.GetMergeRequest(ctx, t.namespace, t.repo, number)
+	if err != nil {
+		return nil, err
+	}
+	return &ChangeRequest{
+		Number:   mr.IID,
+		URL:      mr.WebURL,
+		HeadRef:  mr.SourceBranch,
+		BaseRef:  mr.TargetBranch,
+		HeadSHA:  mr.DiffRefs.HeadSHA,
+		BaseSHA:  mr.DiffRefs.BaseSHA,
+		StartSHA: mr.DiffRefs.StartSHA,
+		FetchRef: fmt.Sprintf("refs/merge-requests/%d/head", mr.IID),
+	}, nil
+}
+
+func (t *gitlabTracker) OpenChangeRequest(ctx context.Context, cr NewChangeRequest) (*ChangeRequest, error) {
+	mr, err := t.c.CreateMergeRequest(ctx, t.namespace, t.repo, cr.Title, cr.Body, cr.Head, cr.Base)
+	if err != nil {
+		return nil, err
+	}
+	return &ChangeRequest{Number: mr.IID, URL: mr.WebURL, HeadRef: mr.SourceBranch, BaseRef: mr.TargetBranch, HeadSHA: mr.SHA}, nil
+}
+
+// Notes returns the first note of every discussion; diff notes carry
+// their line, everything else is a summary.
+func (t *gitlabTracker) Notes(ctx context.Context, cr *ChangeRequest) ([]Note, error) {
+	discussions, err := t.c.ListDiscussions(ctx, t.namespace, t.repo, cr.Number)
+	if err != nil {
+		return nil, err
+	}
+	var out []Note
+	for _, d := range discussions {
+		if len(d.Notes) == 0 || d.Notes[0].System {
+			continue
+		}
+		first := d.Notes[0]
+		n := Note{ID: strconv.Itoa(first.ID), ThreadID: d.ID, Body: first.Body, Resolved: first.Resolved}
+		if first.Type == "DiffNote" {
+			n.Line = 1
+			if first.Position != nil && first.Position.NewLine > 0 {
+				n.Line = first.Position.NewLine
+			}
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+func (t *gitlabTracker) EditNote(ctx context.Context, cr *ChangeRequest, n Note, body string) error {
+	id, err := strconv.Atoi(n.ID)
+	if err != nil {
+		return err
+	}
+	return t.c.UpdateDiscussionNote(ctx, t.namespace, t.repo, cr.Number, n.ThreadID, id, body)
+}
+
+func (t *gitlabTracker) SetResolved(ctx context.Context, cr *ChangeRequest, n Note, resolved bool) error {
+	return t.c.ResolveDiscussion(ctx, t.namespace, t.repo, cr.Number, n.ThreadID, resolved)
+}
+
+// SubmitReview starts one discussion per comment, then posts the summary.
+// GitLab answers 400 for positions outside the diff; those comments go
+// into the summary.
+func (t *gitlabTracker) SubmitReview(ctx context.Context, cr *ChangeRequest, comments []LineComment, summary func([]LineComment) string) (string, error) {
+	var rejected []LineComment
+	for _, c := range comments {
+		old := c.OldPath
+		if old == "" {
+			old = c.Path
+		}
+		pos := &gitlab.Position{
+			PositionType: "text",
+			BaseSHA:      cr.BaseSHA,
+			StartSHA:     cr.StartSHA,
+			HeadSHA:      cr.HeadSHA,
+			OldPath:      old,
+			NewPath:      c.Path,
+			NewLine:      c.Line,
+		}
+		_, err := t.c.CreateDiscussion(ctx, t.namespace, t.repo, cr.Number, c.Body, pos)
+		if errors.Is(err, gitlab.ErrBadRequest) {
+			rejected = append(rejected, c)
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	if _, err := t.c.CreateDiscussion(ctx, t.namespace, t.repo, cr.Number, summary(rejected), nil); err != nil {
+		return "", err
+	}
+	return cr.URL, nil
+}
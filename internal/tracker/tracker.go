@@ -0,0 +1,200 @@
+// Package tracker hides the differences between the issue trackers and code
+// review systems dai publishes to.
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gorankrgovic/dai/internal/gh"
+	"github.com/gorankrgovic/dai/internal/gitea"
+	"github.com/gorankrgovic/dai/internal/gitlab"
+)
+
+// Providers with a Tracker implementation.
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+	Gitea  = "gitea"
+
+	// Forgejo is accepted as another name for Gitea.
+	Forgejo = "forgejo"
+)
+
+// Issue is an issue in any tracker. Number is the per-project number
+// (GitLab's iid).
+type Issue struct {
+	Number int
+	Title  string
+	Body   string
+	URL    string
+	Closed bool
+}
+
+// NewIssue is an issue to create. Assignees are user names.
+type NewIssue struct {
+	Title     string
+	Body      string
+	Labels    []string
+	Assignees []string
+}
+
+// Tracker is an issue tracker scoped to one repository.
+type Tracker interface {
+	Provider() string
+	EnsureLabels(ctx context.Context, labels []string) error
+	CreateIssue(ctx context.Context, is NewIssue) (*Issue, error)
+	Issue(ctx context.Context, number int) (*Issue, error)
+	// ListOpenIssues calls fn for open issues, newest first, until it
+	// returns false.
+	ListOpenIssues(ctx context.Context, fn func(Issue) bool) error
+	SetIssueBody(ctx context.Context, number int, body string) error
+	CloseIssue(ctx context.Context, number int) error
+	AddLabels(ctx context.Context, number int, labels []string) error
+	// Comment comments on an issue and returns a link to the comment.
+	Comment(ctx context.Context, number int, body string) (string, error)
+}
+
+// ChangeRequest is a pull request (GitHub, Gitea) or merge request (GitLab).
+type ChangeRequest struct {
+	Number   int
+	URL      string
+	HeadRef  string
+	BaseRef  string
+	HeadSHA  string
+	BaseSHA  string
+	FetchRef string // ref on the remote that points at the head commit
+
+	// StartSHA is the target branch tip the diff was computed from (GitLab).
+	StartSHA string
+}
+
+// LineComment is a review comment on lines of the new side of the diff.
+type LineComment struct {
+	Path      string
+	OldPath   string
+	StartLine int // 0 for single-line comments
+	Line      int
+	Body      string
+}
+
+// Note is an existing review comment: a line thread (Line > 0) or a
+// summary.
+type Note struct {
+	ID       string
+	ThreadID string
+	Body     string
+	Resolved bool
+	Line     int
+}
+
+// Reviewer posts line comments on change requests.
+type Reviewer interface {
+	ChangeRequest(ctx context.Context, number int) (*ChangeRequest, error)
+	// Notes returns the first comment of every thread and every summary.
+	Notes(ctx context.Context, cr *ChangeRequest) ([]Note, error)
+	EditNote(ctx context.Context, cr *ChangeRequest, n Note, body string) error
+	SetResolved(ctx context.Context, cr *ChangeRequest, n Note, resolved bool) error
+	// SubmitReview posts comments and a summary. Comments the server
+	// refuses to anchor are passed to summary, which renders the body.
+	SubmitReview(ctx context.Context, cr *ChangeRequest, comments []LineComment, summary func(rejected []LineComment) string) (string, error)
+}
+
+// NewChangeRequest is a change request to open from branch Head into
+// Base.
+type NewChangeRequest struct {
+	Title string
+	Body  string
+	Head  string
+	Base  string
+}
+
+// ChangeRequester opens change requests.
+type ChangeRequester interface {
+	OpenChangeRequest(ctx context.Context, cr NewChangeRequest) (*ChangeRequest, error)
+}
+
+// Config selects and authenticates a backend.
+type Config struct {
+	Provider  string
+	APIBase   string
+	Token     string
+	Namespace string
+	Repo      string
+
+	// GitHubTokens replaces Token on GitHub, e.g. with GitHub App
+	// installation tokens.
+	GitHubTokens gh.TokenSource
+}
+
+// New returns the tracker for cfg.Provider ("" means GitHub).
+func New(cfg Config) (Tracker, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case "", GitHub:
+		return newGitHub(cfg), nil
+	case GitLab:
+		return newGitLab(cfg), nil
+	case Gitea, Forgejo:
+		return newGitea(cfg), nil
+	}
+	return nil, fmt.Errorf("provider %q has no issue tracker support", cfg.Provider)
+}
+
+// NewReviewer returns the change request reviewer for cfg.Provider.
+func NewReviewer(cfg Config) (Reviewer, error) {
+	t, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := t.(Reviewer)
+	if !ok {
+		return nil, fmt.Errorf("provider %q has no code review support", cfg.Provider)
+	}
+	return r, nil
+}
+
+// NewChangeRequester returns the change request opener for cfg.Provider.
+func NewChangeRequester(cfg Config) (ChangeRequester, error) {
+	t, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := t.(ChangeRequester)
+	if !ok {
+		return nil, fmt.Errorf("provider %q cannot open change requests", cfg.Provider)
+	}
+	return r, nil
+}
+
+// Supported reports whether New accepts provider.
+func Supported(provider string) bool {
+	_, err := New(Config{Provider: provider})
+	return err == nil
+}
+
+// LabelColor is the color (hex, no '#') used for labels dai creates.
+func LabelColor(name string) string {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "bug":
+		return "d73a4a"
+	case "enhancement":
+		return "a2eeef"
+	case "question":
+		return "d876e3"
+	default:
+		return "cccccc"
+	}
+}
+
+// APIBase returns the default API root of provider on host.
+func APIBase(provider, host string) string {
+	switch strings.ToLower(provider) {
+	case GitLab:
+		return gitlab.APIBase(host)
+	case Gitea, Forgejo:
+		return gitea.APIBase(host)
+	default:
+		return gh.APIBase(host)
+	}
+}
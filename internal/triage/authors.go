@@ -0,0 +1,92 @@
+package triage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gorankrgovic/dai/internal/gitutil"
+	"github.com/gorankrgovic/dai/internal/project"
+)
+
+// Values for Options.AssignAuthor.
+const (
+	AssignNone    = ""
+	AssignIssue   = "assign"  // set the authors as issue assignees
+	AssignMention = "mention" // @-mention the authors in the issue body
+)
+
+func ParseAssignAuthor(s string) (string, error) {
+	switch s = strings.ToLower(strings.TrimSpace(s)); s {
+	case AssignNone, "off", "none":
+		return AssignNone, nil
+	case AssignIssue, AssignMention:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown --assign-author mode %q (use assign or mention)", s)
+}
+
+// attributeAuthors blames the resolved range of every finding and maps the
+// author to a GitHub login via .dai/authors.yaml, the noreply address, or
+// (unless dry-run, and only when AssignAuthor is set) the login GitHub
+// linked to the commit. Other providers only use authors.yaml.
+func attributeAuthors(ctx context.Context, opt Options, commit string, findings []Finding) {
+	authors, err := project.LoadAuthors(opt.Root)
+	if err != nil {
+		authors = &project.Authors{}
+	}
+	client := opt.githubClient()
+	commitLogins := map[string]string{}
+	for i := range findings {
+		f := &findings[i]
+		if f.StartLine == 0 || f.Type == "none" {
+			continue
+		}
+		lines, err := gitutil.Blame(ctx, opt.Root, commit, f.File, f.StartLine, f.EndLine)
+		if err != nil || len(lines) == 0 {
+			continue
+		}
+		top := gitutil.TopAuthor(lines)
+		f.AuthorName, f.AuthorEmail = top.AuthorName, top.AuthorEmail
+		f.AuthorLogin = authors.Login(top.AuthorEmail)
+		if f.AuthorLogin != "" || opt.AssignAuthor == AssignNone || opt.DryRun || !opt.isGitHub() {
+			continue
+		}
+		login, ok := commitLogins[top.Commit]
+		if !ok {
+			login, _ = client.CommitAuthorLogin(ctx, opt.Owner, opt.Repo, top.Commit)
+			commitLogins[top.Commit] = login
+		}
+		f.AuthorLogin = login
+	}
+}
+
+// assignees returns the unique logins of reported findings.
+func assignees(findings []Finding) []string {
+	seen := map[string]struct{}{}
+	var out []string
+	for _, f := range findings {
+		if f.AuthorLogin == "" || (f.Type != "bug" && f.Type != "enhancement") {
+			continue
+		}
+		key := strings.ToLower(f.AuthorLogin)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, f.AuthorLogin)
+	}
+	return out
+}
+
+// authorText renders the author line of a finding in the issue body.
+func authorText(f Finding, mention bool) string {
+	switch {
+	case f.AuthorLogin != "" && mention:
+		return "@" + f.AuthorLogin
+	case f.AuthorLogin != "":
+		return "`" + f.AuthorLogin + "`"
+	default:
+		return safeText(f.AuthorName)
+	}
+}
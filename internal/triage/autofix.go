@@ -0,0 +1,289 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gorankrgovic/dai/internal/gitutil"
+	"github.com/gorankrgovic/dai/internal/tracker"
+)
+
+// AutofixBranchPrefix starts the name of every branch autofix pushes.
+const AutofixBranchPrefix = "dai/autofix-"
+
+// Autofix is the fix proposed for a dai issue and, unless it was a dry
+// run, the change request that carries it.
+type Autofix struct {
+	Issue    int
+	Title    string
+	Findings []MetaFinding // the findings the fix was asked for
+	Summary  string
+	Patch    string // unified diff against Base
+	Base     string
+	Branch   string
+	Commit   string
+	URL      string // change request
+
+	// Validation is nil when no validation command is configured.
+	Validation *Validation
+}
+
+// RunAutofix asks the model for a minimal fix of the open findings of dai
+// issue number and applies it to a throwaway worktree of base, where
+// opt.Validate runs. A fix that fails validation is returned with an
+// ErrValidationFailed error and goes no further. Otherwise, unless
+// opt.DryRun, it is committed on a new branch, pushed to origin and
+// proposed in a change request that closes the issue.
+func RunAutofix(ctx context.Context, opt Options, number int, base string) (*Autofix, error) {
+	t, err := opt.tracker()
+	if err != nil {
+		return nil, err
+	}
+	is, err := t.Issue(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if is.Closed {
+		return nil, fmt.Errorf("issue #%d is closed", number)
+	}
+	meta, ok := ParseIssueMeta(is.Body)
+	if !ok {
+		return nil, fmt.Errorf("issue #%d was not created by dai triage", number)
+	}
+	af := &Autofix{Issue: number, Title: is.Title, Branch: fmt.Sprintf("%s%d", AutofixBranchPrefix, number)}
+	for _, f := range meta.Findings {
+		if !meta.IsFixed(f.FP) {
+			af.Findings = append(af.Findings, f)
+		}
+	}
+	if len(af.Findings) == 0 {
+		return nil, fmt.Errorf("issue #%d has no open findings", number)
+	}
+	if !opt.DryRun {
+		if gitutil.BranchExists(opt.Root, af.Branch) {
+			return nil, fmt.Errorf("branch %s already exists; delete it to retry", af.Branch)
+		}
+		// left by an earlier run, possibly on another machine
+		remote, err := gitutil.RemoteBranchExists(ctx, opt.Root, "origin", af.Branch)
+		if err != nil {
+			return nil, err
+		}
+		if remote {
+			return nil, fmt.Errorf("branch %s already exists on origin; open a pull request from it or delete it to retry", af.Branch)
+		}
+	}
+
+	if base == "" {
+		if base, err = gitutil.DefaultBranch(opt.Root); err != nil {
+			return nil, err
+		}
+	}
+	af.Base = base
+	if err := gitutil.Fetch(ctx, opt.Root, "origin", "+refs/heads/"+base+":refs/remotes/origin/"+base); err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", base, err)
+	}
+	rev := "origin/" + base
+
+	cat, err := gitutil.NewCatFile(ctx, opt.Root, opt.MaxFileBytes)
+	if err != nil {
+		return nil, err
+	}
+	defer cat.Close()
+	summary, changed, err := fixFindings(ctx, opt, af.Findings, func(path string) (string, error) {
+		blob, err := cat.Read(rev, path)
+		if err != nil {
+			return "", err
+		}
+		if blob.Binary || blob.Truncated {
+			return "", errSkipFile
+		}
+		return string(blob.Content), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	af.Summary = summary
+
+	wt, err := gitutil.AddWorktree(ctx, opt.Root, rev)
+	if err != nil {
+		return nil, err
+	}
+	defer wt.Remove()
+	if err := writeFiles(wt.Dir, changed); err != nil {
+		return nil, err
+	}
+	if af.Patch, err = wt.Diff(); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(af.Patch) == "" {
+		return nil, errors.New("the proposed fix does not change anything")
+	}
+	if opt.Validate != nil {
+		if af.Validation, err = runValidation(ctx, wt.Dir, opt.Validate); err != nil {
+			return nil, err
+		}
+		if !af.Validation.Passed {
+			return af, fmt.Errorf("%w: %s", ErrValidationFailed, af.Validation.Summary())
+		}
+	}
+	if opt.DryRun {
+		return af, nil
+	}
+
+	cr, err := opt.changeRequester()
+	if err != nil {
+		return nil, err
+	}
+	title := fmt.Sprintf("Fix #%d: %s", number, safeText(is.Title))
+	if af.Commit, err = wt.Commit(ctx, af.Branch, title+"\n\n"+summary); err != nil {
+		_ = wt.DeleteBranch(af.Branch)
+		return nil, err
+	}
+	if err := gitutil.Push(ctx, opt.Root, "origin", af.Branch); err != nil {
+		// so that a retry starts over
+		if derr := wt.DeleteBranch(af.Branch); derr != nil {
+			return nil, fmt.Errorf("%w (and deleting branch %s: %v)", err, af.Branch, derr)
+		}
+		return nil, err
+	}
+	out, err := cr.OpenChangeRequest(ctx, tracker.NewChangeRequest{
+		Title: title,
+		Body:  autofixBody(af),
+		Head:  af.Branch,
+		Base:  base,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pushed %s to origin but could not open the pull request; open it by hand: %w", af.Branch, err)
+	}
+	af.URL = out.URL
+	return af, nil
+}
+
+func autofixBody(af *Autofix) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Fixes #%d.\n\n", af.Issue)
+	if af.Summary != "" {
+		sb.WriteString(af.Summary + "\n\n")
+	}
+	sb.WriteString("Findings addressed:\n\n")
+	for _, f := range af.Findings {
+		fmt.Fprintf(&sb, "- **%s** (`%s`)\n", safeText(f.Title), f.File)
+	}
+	if af.Validation != nil {
+		sb.WriteString("\n" + af.Validation.Markdown())
+	} else {
+		sb.WriteString("\nValidation was skipped; the change was not built or tested.\n")
+	}
+	sb.WriteString("\n_Proposed by `dai autofix`; review it like any other change._\n")
+	return sb.String()
+}
+
+// errSkipFile makes fixFindings leave a file out of the prompt.
+var errSkipFile = errors.New("file skipped")
+
+// fixFindings reads the files of findings through read, asks the model
+// for a fix and returns its summary and the new content of every file it
+// changed. Files that read reports missing or errSkipFile are left out.
+func fixFindings(ctx context.Context, opt Options, findings []MetaFinding, read func(path string) (string, error)) (string, map[string]string, error) {
+	files := map[string]string{}
+	excerpts := map[string]string{}
+	for _, f := range findings {
+		if _, ok := files[f.File]; ok {
+			continue
+		}
+		content, err := read(f.File)
+		if errors.Is(err, gitutil.ErrObjectNotFound) || errors.Is(err, os.ErrNotExist) || errors.Is(err, errSkipFile) {
+			continue
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("read %s: %w", f.File, err)
+		}
+		files[f.File] = content
+		start, end := findingSpan(findings, f.File)
+		excerpts[f.File] = numberedExcerpt(content, start, end)
+	}
+	if len(files) == 0 {
+		return "", nil, errors.New("none of the files the findings point to can be read")
+	}
+
+	out, err := proposeFix(ctx, opt.OpenAIKey, opt.Model, findings, excerpts)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(out.Edits) == 0 {
+		return "", nil, errors.New("the model proposed no fix")
+	}
+	changed, err := applyEdits(files, out.Edits)
+	if err != nil {
+		return "", nil, err
+	}
+	return out.Summary, changed, nil
+}
+
+// findingSpan covers the lines of every finding in file.
+func findingSpan(findings []MetaFinding, file string) (start, end int) {
+	for _, f := range findings {
+		if f.File != file || f.Start <= 0 {
+			continue
+		}
+		if start == 0 || f.Start < start {
+			start = f.Start
+		}
+		end = max(end, f.End, f.Start)
+	}
+	return start, end
+}
+
+// applyEdits replaces the find text of each edit, which must occur exactly
+// once, and returns the files that changed.
+func applyEdits(files map[string]string, edits []fixEdit) (map[string]string, error) {
+	changed := map[string]string{}
+	for i, e := range edits {
+		e.File = filepath.ToSlash(strings.TrimPrefix(strings.TrimSpace(e.File), "./"))
+		content, ok := changed[e.File]
+		if !ok {
+			if content, ok = files[e.File]; !ok {
+				return nil, fmt.Errorf("edit %d: %s is not one of the files to fix", i+1, e.File)
+			}
+		}
+		if e.Find == "" {
+			return nil, fmt.Errorf("edit %d (%s): empty find text", i+1, e.File)
+		}
+		switch n := strings.Count(content, e.Find); n {
+		case 1:
+		case 0:
+			return nil, fmt.Errorf("edit %d (%s): find text does not occur in the file", i+1, e.File)
+		default:
+			return nil, fmt.Errorf("edit %d (%s): find text occurs %d times", i+1, e.File, n)
+		}
+		changed[e.File] = strings.Replace(content, e.Find, e.Replace, 1)
+	}
+	for p, c := range changed {
+		if c == files[p] {
+			delete(changed, p)
+		}
+	}
+	return changed, nil
+}
+
+// writeFiles writes files below dir, keeping each file's mode.
+func writeFiles(dir string, files map[string]string) error {
+	for p, content := range files {
+		full := filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			return err
+		}
+		mode := os.FileMode(0o644)
+		if st, err := os.Stat(full); err == nil {
+			mode = st.Mode().Perm()
+		}
+		if err := os.WriteFile(full, []byte(content), mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
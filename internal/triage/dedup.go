@@ -0,0 +1,93 @@
+package triage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gorankrgovic/dai/internal/tracker"
+)
+
+// maxDedupScan bounds how many open issues are searched for a marker.
+const maxDedupScan = 1000
+
+// findTriageIssue returns the open dai issue that already covers the
+// commit of meta or, failing that, the newest one sharing a finding with it
+// (a re-triaged or amended commit).
+func findTriageIssue(ctx context.Context, t tracker.Tracker, meta *IssueMeta) (*tracker.Issue, *IssueMeta, error) {
+	var (
+		byCommit, byFinding *tracker.Issue
+		commitMeta, fpMeta  *IssueMeta
+		scanned             int
+	)
+	fps := meta.fingerprints()
+	err := t.ListOpenIssues(ctx, func(is tracker.Issue) bool {
+		scanned++
+		m, ok := ParseIssueMeta(is.Body)
+		if !ok {
+			return scanned < maxDedupScan
+		}
+		if m.HasCommit(meta.Commits[0]) {
+			byCommit, commitMeta = &is, m
+			return false
+		}
+		if byFinding == nil {
+			for _, f := range m.Findings {
+				if fps[f.FP] {
+					byFinding, fpMeta = &is, m
+					break
+				}
+			}
+		}
+		return scanned < maxDedupScan
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if byCommit != nil {
+		return byCommit, commitMeta, nil
+	}
+	return byFinding, fpMeta, nil
+}
+
+// updateTriageIssue records meta in the existing issue and comments with
+// the findings it did not list yet.
+func updateTriageIssue(ctx context.Context, t tracker.Tracker, opt Options, a *Analysis, issue *tracker.Issue, prev, meta *IssueMeta, res *Result) error {
+	res.URL, res.Number, res.Updated = issue.URL, issue.Number, true
+	newCommit := !prev.HasCommit(a.Commit)
+	added := prev.merge(meta)
+	if len(added) > 0 {
+		addedFP := make(map[string]bool, len(added))
+		for _, f := range added {
+			addedFP[f.FP] = true
+		}
+		var fresh []Finding
+		for _, f := range a.Findings {
+			if addedFP[Fingerprint(f)] {
+				fresh = append(fresh, f)
+			}
+		}
+		_, body, labels := summarize(report{
+			Commit:         a.Commit,
+			Scope:          a.Scope,
+			Findings:       fresh,
+			MentionAuthors: opt.AssignAuthor == AssignMention,
+		})
+		if err := t.EnsureLabels(ctx, labels); err != nil {
+			return fmt.Errorf("ensure labels: %w", err)
+		}
+		if err := t.AddLabels(ctx, issue.Number, labels); err != nil {
+			return err
+		}
+		if _, err := t.Comment(ctx, issue.Number, body); err != nil {
+			return err
+		}
+		res.NewFindings = len(added)
+	}
+	if newCommit || len(added) > 0 {
+		body := withIssueMeta(issue.Body, prev)
+		if err := t.SetIssueBody(ctx, issue.Number, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
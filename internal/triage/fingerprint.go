@@ -0,0 +1,23 @@
+package triage
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"unicode"
+)
+
+// Fingerprint identifies a finding across runs by its file, type and
+// title, folded to lower-case letters and digits. Line numbers are left out
+// so it survives edits elsewhere in the file.
+func Fingerprint(f Finding) string {
+	var title strings.Builder
+	for _, w := range strings.FieldsFunc(strings.ToLower(f.Title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		title.WriteString(w)
+		title.WriteByte(' ')
+	}
+	sum := sha1.Sum([]byte(f.File + "\x00" + strings.ToLower(f.Type) + "\x00" + title.String()))
+	return hex.EncodeToString(sum[:6])
+}
@@ -0,0 +1,164 @@
+package triage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gorankrgovic/dai/internal/jira"
+	"github.com/gorankrgovic/dai/internal/project"
+)
+
+// Jira ticket modes (project.Jira.Per).
+const (
+	JiraPerRun     = "run"
+	JiraPerFinding = "finding"
+)
+
+// JiraTicket is an issue publishJira created.
+type JiraTicket struct {
+	Key      string
+	URL      string
+	Findings int
+}
+
+// ValidateJira checks the jira section of project.yaml.
+func ValidateJira(cfg *project.Jira) error {
+	if cfg == nil {
+		return fmt.Errorf("no 'jira' section in .dai/project.yaml")
+	}
+	if cfg.URL == "" || cfg.Project == "" {
+		return fmt.Errorf("jira: 'url' and 'project' are required in .dai/project.yaml")
+	}
+	switch cfg.Per {
+	case "", JiraPerRun, JiraPerFinding:
+	default:
+		return fmt.Errorf("jira: unknown 'per' %q (use run or finding)", cfg.Per)
+	}
+	return nil
+}
+
+// publishJira files the reported findings of res in Jira, one ticket per
+// run or per finding. Findings the local record already links to a ticket
+// are left out, and nothing is created when none remain.
+func publishJira(ctx context.Context, opt Options, res *Result) error {
+	cfg := opt.Jira
+	if err := ValidateJira(cfg); err != nil {
+		return err
+	}
+	rec, err := LoadRecord(opt.Root)
+	if err != nil {
+		return fmt.Errorf("read findings record: %w", err)
+	}
+	var fresh []Finding
+	for _, f := range res.Findings {
+		if f.Type != "bug" && f.Type != "enhancement" {
+			continue
+		}
+		if rec.Findings[Fingerprint(f)].Jira == "" {
+			fresh = append(fresh, f)
+		}
+	}
+	if len(fresh) == 0 {
+		return nil
+	}
+
+	client := jira.NewClient(cfg.URL, opt.JiraToken)
+	issueType := cfg.IssueType
+	if issueType == "" {
+		issueType = "Bug"
+	}
+	file := func(summary, description, severity string, findings []Finding) error {
+		key, err := client.CreateIssue(ctx, jira.NewIssue{
+			Project:     cfg.Project,
+			IssueType:   issueType,
+			Summary:     summary,
+			Description: description,
+			Priority:    cfg.Priorities[severity],
+			Labels:      cfg.Labels,
+		})
+		if err != nil {
+			return err
+		}
+		res.JiraTickets = append(res.JiraTickets, JiraTicket{Key: key, URL: client.BrowseURL(key), Findings: len(findings)})
+		for _, f := range findings {
+			rec.Findings[Fingerprint(f)] = RecordEntry{Commit: res.Commit, File: f.File, Title: f.Title, Jira: key, Filed: time.Now().UTC()}
+		}
+		// saved after every ticket so a failure later in the run does not
+		// lose the keys created so far
+		return rec.Save(opt.Root)
+	}
+
+	if cfg.Per == JiraPerFinding {
+		for _, f := range fresh {
+			summary := fmt.Sprintf("[DAI] %s: %s", findingKind(f), safeText(f.Title))
+			if err := file(summary, jiraFindingText(f, res.Commit)+jiraFooter, f.Severity, []Finding{f}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	summary := fmt.Sprintf("[DAI] %d finding(s) in commit %.8s", len(fresh), res.Commit)
+	if res.Commit == "" {
+		summary = fmt.Sprintf("[DAI] %d finding(s) in staged changes", len(fresh))
+	}
+	return file(summary, jiraRunText(fresh, res), highestSeverity(fresh), fresh)
+}
+
+func findingKind(f Finding) string {
+	if f.Type == "enhancement" {
+		return "Suggestion"
+	}
+	return "Bug"
+}
+
+func highestSeverity(findings []Finding) string {
+	best := ""
+	for _, f := range findings {
+		if severityRank[f.Severity] > severityRank[best] {
+			best = f.Severity
+		}
+	}
+	return best
+}
+
+const jiraFooter = "\n_Reported by DAI triage._"
+
+// jiraFindingText renders a finding in Jira wiki markup.
+func jiraFindingText(f Finding, commit string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%s*", findingKind(f))
+	if f.Severity != "" {
+		fmt.Fprintf(&sb, " (%s)", strings.ToUpper(f.Severity))
+	}
+	fmt.Fprintf(&sb, " in {{%s}}", f.File)
+	if l := linesText(f); l != "" {
+		fmt.Fprintf(&sb, ", lines %s", l)
+	}
+	sb.WriteString("\n")
+	if commit != "" {
+		fmt.Fprintf(&sb, "Commit: {{%s}}\n", commit)
+	}
+	if f.Details != "" {
+		fmt.Fprintf(&sb, "\n%s\n", f.Details)
+	}
+	if f.AuthorName != "" {
+		fmt.Fprintf(&sb, "\nAuthor: %s\n", safeText(f.AuthorName))
+	}
+	return sb.String()
+}
+
+func jiraRunText(findings []Finding, res *Result) string {
+	var sb strings.Builder
+	if res.Scope != "" {
+		fmt.Fprintf(&sb, "Scope: %s\n", res.Scope)
+	}
+	for _, f := range findings {
+		fmt.Fprintf(&sb, "\nh3. %s\n%s\n", safeText(f.Title), jiraFindingText(f, ""))
+	}
+	if res.Commit != "" {
+		fmt.Fprintf(&sb, "\nCommit: {{%s}}\n", res.Commit)
+	}
+	return sb.String() + jiraFooter
+}
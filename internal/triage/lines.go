@@ -0,0 +1,40 @@
+package triage
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/gorankrgovic/dai/internal/gitutil"
+)
+
+var reLineHint = regexp.MustCompile(`(\d+)(?:\s*(?:-|–|to)\s*(\d+))?`)
+
+// resolveLines settles f.StartLine/EndLine on a range that overlaps the
+// new side of the diff. The model's explicit numbers win; free-form
+// line_hints are the fallback. Unmatched ranges are cleared.
+func resolveLines(f *Finding, fd gitutil.FileDiff) {
+	start, end := f.StartLine, f.EndLine
+	if start <= 0 {
+		if m := reLineHint.FindStringSubmatch(f.LineHints); m != nil {
+			start, _ = strconv.Atoi(m[1])
+			end, _ = strconv.Atoi(m[2])
+		}
+	}
+	if end < start {
+		end = start
+	}
+	f.StartLine, f.EndLine = 0, 0
+	if start <= 0 {
+		return
+	}
+	for _, h := range fd.Hunks {
+		if h.NewLines == 0 {
+			continue
+		}
+		lo, hi := h.NewStart, h.NewStart+h.NewLines-1
+		if start <= hi && end >= lo {
+			f.StartLine, f.EndLine = max(start, lo), min(end, hi)
+			return
+		}
+	}
+}
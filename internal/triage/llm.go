@@ -3,8 +3,12 @@ package triage
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/gorankrgovic/dai/internal/gitutil"
+
 	openai "github.com/sashabaranov/go-openai"
 )
 
@@ -14,28 +18,50 @@ type modelOutput struct {
 	Details   string `json:"details"`
 	Severity  string `json:"severity"`   // low|medium|high (optional)
 	LineHints string `json:"line_hints"` // e.g. "approx lines 120-140"
+	StartLine int    `json:"start_line"` // new-file line numbers (diff only)
+	EndLine   int    `json:"end_line"`
 }
 
 // ------- NEW: diff analiza --------
 
-func analyzeDiff(ctx context.Context, apiKey, model, path string, diffBlocks []string) (Finding, error) {
+func analyzeDiff(ctx context.Context, apiKey, model string, fd gitutil.FileDiff, diffBlocks []string) (Finding, error) {
+	path := fd.Path
 	sys := `You are a senior code reviewer focused on DIFFS. Output STRICT JSON ONLY (no prose), schema:
 {
   "type": "bug" | "enhancement" | "none",
   "title": "short one-line summary",
   "details": "short explanation for developers",
   "severity": "low|medium|high",
-  "line_hints": "optional location hints"
+  "line_hints": "optional location hints",
+  "start_line": 0,
+  "end_line": 0
 }
 Rules:
 - You are given a unified diff (with minimal context).
+- "start_line"/"end_line" are line numbers in the NEW version of the file (count from the "+" side of the @@ headers); use 0 if unsure.
 - Focus on ADDED code (lines starting with '+'). Use surrounding context to reason.
 - If nothing stands out, return "none". Keep it specific.`
 
 	var b strings.Builder
 	b.WriteString("FILE PATH: ")
 	b.WriteString(path)
-	b.WriteString("\n\n")
+	b.WriteString("\n")
+	switch fd.Status {
+	case gitutil.StatusAdded:
+		b.WriteString("STATUS: new file\n")
+	case gitutil.StatusRenamed, gitutil.StatusCopied:
+		fmt.Fprintf(&b, "STATUS: %s from %s (%d%% similar)\n", fd.Status, fd.OldPath, fd.Similarity)
+	}
+	switch fd.Merge {
+	case gitutil.MergeConflicts:
+		b.WriteString("MERGE: hunks show how merge conflicts were resolved; '+' lines are in the merge result but missing from at least one parent\n")
+	case gitutil.MergeEvil:
+		b.WriteString("MERGE: hunks show changes the merge introduced that exist in neither parent (evil merge)\n")
+	}
+	if fd.ModeChanged() {
+		fmt.Fprintf(&b, "MODE: %s -> %s\n", fd.OldMode, fd.NewMode)
+	}
+	b.WriteString("\n")
 	b.WriteString("DIFF (unified):\n```diff\n")
 	for _, block := range diffBlocks {
 		b.WriteString(block)
@@ -62,8 +88,36 @@ Rules:
 	out := modelOutput{}
 	raw := ""
 	if len(resp.Choices) > 0 {
-		raw = strings.TrimSpace(resp.Choices[0].Message.Content)
+		raw = resp.Choices[0].Message.Content
+	}
+	raw = extractJSON(raw)
+	_ = json.Unmarshal([]byte(raw), &out)
+
+	typ := strings.ToLower(strings.TrimSpace(out.Type))
+	if typ != "bug" && typ != "enhancement" && typ != "none" {
+		typ = "none"
 	}
+	sev := strings.ToLower(strings.TrimSpace(out.Severity))
+	if sev != "low" && sev != "medium" && sev != "high" {
+		sev = ""
+	}
+
+	return Finding{
+		File:      path,
+		Type:      typ,
+		Title:     strings.TrimSpace(out.Title),
+		Details:   strings.TrimSpace(out.Details),
+		Severity:  sev,
+		LineHints: strings.TrimSpace(out.LineHints),
+		StartLine: out.StartLine,
+		EndLine:   out.EndLine,
+	}, nil
+}
+
+// extractJSON strips code fences and prose around the JSON object in a
+// model reply.
+func extractJSON(raw string) string {
+	raw = strings.TrimSpace(raw)
 
 	// remove code-fence
 	low := strings.ToLower(raw)
@@ -85,23 +139,141 @@ Rules:
 			}
 		}
 	}
-	_ = json.Unmarshal([]byte(raw), &out)
+	return raw
+}
 
-	typ := strings.ToLower(strings.TrimSpace(out.Type))
-	if typ != "bug" && typ != "enhancement" && typ != "none" {
-		typ = "none"
+type recheckOutput struct {
+	Present bool   `json:"present"`
+	Reason  string `json:"reason"`
+}
+
+// recheckFinding asks whether an earlier finding still applies to the
+// current version of its file. excerpt carries line numbers.
+func recheckFinding(ctx context.Context, apiKey, model string, f MetaFinding, excerpt string) (bool, string, error) {
+	sys := `You verify earlier code review findings against the CURRENT code. Output STRICT JSON ONLY (no prose), schema:
+{
+  "present": true | false,
+  "reason": "one sentence explaining why"
+}
+Rules:
+- "present" is true when the current code still has the problem described, anywhere in the excerpt.
+- Line numbers may have moved since the finding was reported.
+- Answer false only when the problematic code is gone or clearly fixed.`
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "FILE PATH: %s\n", f.File)
+	fmt.Fprintf(&b, "FINDING (%s", f.Type)
+	if f.Severity != "" {
+		fmt.Fprintf(&b, ", %s", f.Severity)
 	}
-	sev := strings.ToLower(strings.TrimSpace(out.Severity))
-	if sev != "low" && sev != "medium" && sev != "high" {
-		sev = ""
+	fmt.Fprintf(&b, "): %s\n", f.Title)
+	if f.Details != "" {
+		fmt.Fprintf(&b, "DETAILS: %s\n", f.Details)
+	}
+	if f.Start > 0 {
+		fmt.Fprintf(&b, "ORIGINALLY AT LINES: %d-%d\n", f.Start, max(f.End, f.Start))
 	}
+	b.WriteString("\nCURRENT CODE (line-numbered):\n```\n")
+	b.WriteString(excerpt)
+	b.WriteString("```\n")
 
-	return Finding{
-		File:      path,
-		Type:      typ,
-		Title:     strings.TrimSpace(out.Title),
-		Details:   strings.TrimSpace(out.Details),
-		Severity:  sev,
-		LineHints: strings.TrimSpace(out.LineHints),
-	}, nil
+	client := openai.NewClient(apiKey)
+	resp, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: sys},
+			{Role: openai.ChatMessageRoleUser, Content: b.String()},
+		},
+		Temperature: 0,
+	})
+	if err != nil {
+		return true, "", err
+	}
+	if len(resp.Choices) == 0 {
+		return true, "", fmt.Errorf("empty model response")
+	}
+	// a reply we cannot read keeps the finding open
+	out := recheckOutput{Present: true}
+	if err := json.Unmarshal([]byte(extractJSON(resp.Choices[0].Message.Content)), &out); err != nil {
+		return true, "", fmt.Errorf("parse model response: %w", err)
+	}
+	return out.Present, strings.TrimSpace(out.Reason), nil
+}
+
+type fixEdit struct {
+	File    string `json:"file"`
+	Find    string `json:"find"`
+	Replace string `json:"replace"`
+}
+
+type fixOutput struct {
+	Summary string    `json:"summary"`
+	Edits   []fixEdit `json:"edits"`
+}
+
+// proposeFix asks for the smallest edits that fix findings. excerpts maps
+// paths to line-numbered excerpts of their current content.
+func proposeFix(ctx context.Context, apiKey, model string, findings []MetaFinding, excerpts map[string]string) (fixOutput, error) {
+	sys := `You fix code review findings with the SMALLEST possible change. Output STRICT JSON ONLY (no prose), schema:
+{
+  "summary": "one or two sentences describing the fix",
+  "edits": [
+    {"file": "path as given", "find": "exact text to replace", "replace": "new text"}
+  ]
+}
+Rules:
+- "find" is copied VERBATIM from the file (without the line-number prefixes), including indentation, and occurs EXACTLY ONCE in it; include a few surrounding lines to make it unique.
+- Edits are applied in order; a later edit sees the result of earlier ones.
+- Only edit the files given. Do not reformat, rename or refactor unrelated code.
+- Fix what the findings describe and nothing else; keep the code style of the file.
+- If a finding cannot be fixed safely, leave it out; return no edits when nothing can be fixed.`
+
+	var b strings.Builder
+	b.WriteString("FINDINGS:\n")
+	for i, f := range findings {
+		fmt.Fprintf(&b, "%d. [%s", i+1, f.Type)
+		if f.Severity != "" {
+			fmt.Fprintf(&b, ", %s", f.Severity)
+		}
+		fmt.Fprintf(&b, "] %s — %s", f.File, f.Title)
+		if f.Start > 0 {
+			fmt.Fprintf(&b, " (around lines %d-%d)", f.Start, max(f.End, f.Start))
+		}
+		b.WriteString("\n")
+		if f.Details != "" {
+			fmt.Fprintf(&b, "   %s\n", f.Details)
+		}
+	}
+	paths := make([]string, 0, len(excerpts))
+	for p := range excerpts {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		fmt.Fprintf(&b, "\nFILE: %s (line-numbered)\n```\n", p)
+		b.WriteString(excerpts[p])
+		b.WriteString("```\n")
+	}
+
+	client := openai.NewClient(apiKey)
+	resp, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: sys},
+			{Role: openai.ChatMessageRoleUser, Content: b.String()},
+		},
+		Temperature: 0,
+	})
+	if err != nil {
+		return fixOutput{}, err
+	}
+	if len(resp.Choices) == 0 {
+		return fixOutput{}, fmt.Errorf("empty model response")
+	}
+	var out fixOutput
+	if err := json.Unmarshal([]byte(extractJSON(resp.Choices[0].Message.Content)), &out); err != nil {
+		return fixOutput{}, fmt.Errorf("parse model response: %w", err)
+	}
+	out.Summary = strings.TrimSpace(out.Summary)
+	return out, nil
 }
@@ -0,0 +1,201 @@
+package triage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gorankrgovic/dai/internal/gitutil"
+)
+
+// LoggedFinding is an entry of the triage-local log.
+type LoggedFinding struct {
+	Time    time.Time
+	Model   string
+	Finding Finding
+}
+
+// LastLocalFinding returns the newest triage-local log entry for file
+// (relative to the project root), in either log format. ok is false when
+// the file has no entry or the newest one found nothing.
+func LastLocalFinding(logPath, file string) (LoggedFinding, bool, error) {
+	f, err := os.Open(logPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return LoggedFinding{}, false, nil
+	}
+	if err != nil {
+		return LoggedFinding{}, false, err
+	}
+	defer f.Close()
+
+	file = filepath.Clean(file)
+	var (
+		last  LoggedFinding
+		found bool
+		cur   *LoggedFinding // md entry being read
+		body  []string
+	)
+	finish := func() {
+		if cur != nil && filepath.Clean(cur.Finding.File) == file {
+			cur.Finding.Details = strings.TrimSpace(strings.Join(body, "\n"))
+			last, found = *cur, true
+		}
+		cur, body = nil, nil
+	}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "{") {
+			finish()
+			// older logs have no newline after JSON entries
+			dec := json.NewDecoder(strings.NewReader(line))
+			for {
+				var e localLogEntry
+				if err := dec.Decode(&e); err != nil {
+					break
+				}
+				if filepath.Clean(e.File) == file {
+					last, found = e.logged(), true
+				}
+			}
+			line = strings.TrimSpace(line[dec.InputOffset():])
+		}
+		switch {
+		case strings.HasPrefix(line, "### "):
+			finish()
+			cur = parseLocalLogHeader(strings.TrimPrefix(line, "### "))
+		case cur == nil:
+		case line == "---":
+			finish()
+		case strings.HasPrefix(line, "- Type: ") && len(body) == 0:
+			cur.Finding.Type = strings.ToLower(strings.Trim(strings.TrimPrefix(line, "- Type: "), "*"))
+		case strings.HasPrefix(line, "- Title: ") && len(body) == 0:
+			cur.Finding.Title = strings.TrimPrefix(line, "- Title: ")
+		case strings.HasPrefix(line, "- Severity: ") && len(body) == 0:
+			cur.Finding.Severity = strings.ToLower(strings.TrimPrefix(line, "- Severity: "))
+		case strings.HasPrefix(line, "- Lines: ") && len(body) == 0:
+			cur.Finding.LineHints = strings.TrimPrefix(line, "- Lines: ")
+		default:
+			body = append(body, line)
+		}
+	}
+	finish()
+	if err := sc.Err(); err != nil {
+		return LoggedFinding{}, false, err
+	}
+	if !found || (last.Finding.Type != "bug" && last.Finding.Type != "enhancement") {
+		return LoggedFinding{}, false, nil
+	}
+	return last, true, nil
+}
+
+// localLogEntry is a triage-local log entry in the json format.
+type localLogEntry struct {
+	Time      string `json:"time"`
+	File      string `json:"file"`
+	Model     string `json:"model"`
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Severity  string `json:"severity"`
+	LineHints string `json:"lineHints"`
+	Details   string `json:"details"`
+}
+
+func (e localLogEntry) logged() LoggedFinding {
+	t, _ := time.Parse(time.RFC3339, e.Time)
+	return LoggedFinding{Time: t, Model: e.Model, Finding: Finding{
+		File: e.File, Type: e.Type, Title: e.Title, Severity: e.Severity, LineHints: e.LineHints, Details: e.Details,
+	}}
+}
+
+// parseLocalLogHeader reads "<time> — <file> (model: <m>, truncated: <b>)".
+func parseLocalLogHeader(h string) *LoggedFinding {
+	ts, rest, ok := strings.Cut(h, " — ")
+	if !ok {
+		return nil
+	}
+	e := &LoggedFinding{}
+	e.Time, _ = time.Parse(time.RFC3339, ts)
+	if i := strings.LastIndex(rest, " (model: "); i >= 0 {
+		e.Model, _, _ = strings.Cut(rest[i+len(" (model: "):], ",")
+		rest = rest[:i]
+	}
+	e.Finding.File = rest
+	return e
+}
+
+// LocalFix is a proposed fix for a file in the working tree.
+type LocalFix struct {
+	File    string // relative to the project root
+	Summary string
+	Old     string
+	New     string
+
+	// Validation is nil when no validation command is configured.
+	Validation *Validation
+}
+
+// FixLocal asks the model for a minimal fix of f in its working tree file
+// (f.File relative to opt.Root). When opt.Validate is set the fix is first
+// checked in a throwaway worktree of the current working tree, untracked
+// files included; one that fails is returned with an ErrValidationFailed
+// error.
+func FixLocal(ctx context.Context, opt Options, f Finding) (*LocalFix, error) {
+	file := filepath.ToSlash(filepath.Clean(f.File))
+	mf := MetaFinding{File: file, Type: f.Type, Severity: f.Severity, Title: f.Title, Details: f.Details}
+	if f.LineHints != "" {
+		mf.Details = strings.TrimSpace(mf.Details + "\nLocation: " + f.LineHints)
+	}
+	fix := &LocalFix{File: file}
+	summary, changed, err := fixFindings(ctx, opt, []MetaFinding{mf}, func(path string) (string, error) {
+		b, err := os.ReadFile(filepath.Join(opt.Root, filepath.FromSlash(path)))
+		if err != nil {
+			return "", err
+		}
+		if opt.MaxFileBytes > 0 && int64(len(b)) > opt.MaxFileBytes {
+			return "", fmt.Errorf("larger than %d KB", opt.MaxFileBytes/1024)
+		}
+		fix.Old = string(b)
+		return fix.Old, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	if fix.New, ok = changed[file]; !ok {
+		return nil, errors.New("the proposed fix does not change anything")
+	}
+	fix.Summary = summary
+
+	if opt.Validate != nil {
+		snap, err := gitutil.Snapshot(opt.Root)
+		if err != nil {
+			return nil, err
+		}
+		wt, err := gitutil.AddWorktree(ctx, opt.Root, snap)
+		if err != nil {
+			return nil, err
+		}
+		defer wt.Remove()
+		if err := wt.CopyUntracked(opt.Root); err != nil {
+			return nil, err
+		}
+		if err := writeFiles(wt.Dir, changed); err != nil {
+			return nil, err
+		}
+		if fix.Validation, err = runValidation(ctx, wt.Dir, opt.Validate); err != nil {
+			return nil, err
+		}
+		if !fix.Validation.Passed {
+			return fix, fmt.Errorf("%w: %s", ErrValidationFailed, fix.Validation.Summary())
+		}
+	}
+	return fix, nil
+}
@@ -0,0 +1,155 @@
+package triage
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// IssueMeta is embedded in every triage issue as a hidden HTML comment so
+// later runs (dedup, reconcile, autofix) can read back what was reported.
+type IssueMeta struct {
+	Version  int           `json:"v"`
+	Commits  []string      `json:"commits"`
+	Findings []MetaFinding `json:"findings"`
+	Fixed    []string      `json:"fixed,omitempty"` // fingerprints reconcile found fixed
+}
+
+type MetaFinding struct {
+	FP       string `json:"fp"`
+	Commit   string `json:"commit"`
+	File     string `json:"file"`
+	Start    int    `json:"start,omitempty"`
+	End      int    `json:"end,omitempty"`
+	Type     string `json:"type"`
+	Severity string `json:"severity,omitempty"`
+	Title    string `json:"title"`
+	Details  string `json:"details,omitempty"`
+}
+
+const issueMetaVersion = 1
+
+var reIssueMeta = regexp.MustCompile(`(?s)<!-- dai:triage (\{.*?\}) -->`)
+
+func newIssueMeta(commit string, findings []Finding) *IssueMeta {
+	m := &IssueMeta{Version: issueMetaVersion, Commits: []string{commit}}
+	for _, f := range findings {
+		if f.Type != "bug" && f.Type != "enhancement" {
+			continue
+		}
+		m.Findings = append(m.Findings, MetaFinding{
+			FP:       Fingerprint(f),
+			Commit:   commit,
+			File:     f.File,
+			Start:    f.StartLine,
+			End:      f.EndLine,
+			Type:     f.Type,
+			Severity: f.Severity,
+			Title:    f.Title,
+			Details:  f.Details,
+		})
+	}
+	return m
+}
+
+// Finding converts the metadata back into a Finding.
+func (mf MetaFinding) Finding() Finding {
+	return Finding{
+		File:      mf.File,
+		Type:      mf.Type,
+		Title:     mf.Title,
+		Details:   mf.Details,
+		Severity:  mf.Severity,
+		StartLine: mf.Start,
+		EndLine:   mf.End,
+	}
+}
+
+// Marker renders the hidden comment. encoding/json escapes '<' and '>', so
+// finding text cannot terminate the comment early.
+func (m *IssueMeta) Marker() string {
+	b, _ := json.Marshal(m)
+	return "<!-- dai:triage " + string(b) + " -->"
+}
+
+func (m *IssueMeta) HasCommit(sha string) bool {
+	for _, c := range m.Commits {
+		if c == sha {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *IssueMeta) IsFixed(fp string) bool {
+	for _, f := range m.Fixed {
+		if f == fp {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *IssueMeta) unfix(fp string) {
+	kept := m.Fixed[:0]
+	for _, f := range m.Fixed {
+		if f != fp {
+			kept = append(kept, f)
+		}
+	}
+	m.Fixed = kept
+}
+
+func (m *IssueMeta) fingerprints() map[string]bool {
+	set := make(map[string]bool, len(m.Findings))
+	for _, f := range m.Findings {
+		set[f.FP] = true
+	}
+	return set
+}
+
+// merge adds the commit and the findings of o that m does not have yet (or
+// had marked fixed) and returns those findings.
+func (m *IssueMeta) merge(o *IssueMeta) []MetaFinding {
+	for _, c := range o.Commits {
+		if !m.HasCommit(c) {
+			m.Commits = append(m.Commits, c)
+		}
+	}
+	have := m.fingerprints()
+	var added []MetaFinding
+	for _, f := range o.Findings {
+		switch {
+		case !have[f.FP]:
+			have[f.FP] = true
+			added = append(added, f)
+			m.Findings = append(m.Findings, f)
+		case m.IsFixed(f.FP):
+			// reported as fixed earlier, but it is back
+			m.unfix(f.FP)
+			added = append(added, f)
+		}
+	}
+	return added
+}
+
+// ParseIssueMeta extracts the metadata from an issue body.
+func ParseIssueMeta(body string) (*IssueMeta, bool) {
+	m := reIssueMeta.FindStringSubmatch(body)
+	if m == nil {
+		return nil, false
+	}
+	var meta IssueMeta
+	if err := json.Unmarshal([]byte(m[1]), &meta); err != nil {
+		return nil, false
+	}
+	return &meta, true
+}
+
+// withIssueMeta replaces the marker in body, or appends one.
+func withIssueMeta(body string, m *IssueMeta) string {
+	if loc := reIssueMeta.FindStringIndex(body); loc != nil {
+		return body[:loc[0]] + m.Marker() + body[loc[1]:]
+	}
+	return strings.TrimRight(body, "\n") + "\n\n" + m.Marker() + "\n"
+}
@@ -0,0 +1,147 @@
+package triage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gorankrgovic/dai/internal/notify"
+	"github.com/gorankrgovic/dai/internal/project"
+)
+
+// maxNotifyItems is how many findings a notification lists.
+const maxNotifyItems = 5
+
+// Notification is the outcome of one configured sink. Skipped sinks had
+// no finding that passed their filter.
+type Notification struct {
+	Sink    string
+	Skipped bool
+	Err     error
+}
+
+// ValidateNotify checks the notify section of project.yaml.
+func ValidateNotify(sinks []project.Notify) error {
+	for i, n := range sinks {
+		switch n.Type {
+		case notify.Slack, notify.Teams, notify.Mattermost, notify.Webhook:
+		default:
+			return fmt.Errorf("notify[%d]: unknown type %q (use slack, teams, mattermost or webhook)", i, n.Type)
+		}
+		if n.URL == "" {
+			return fmt.Errorf("notify[%d]: url is required", i)
+		}
+		if name := foreignNotifyVar(n.URL + n.Secret); name != "" {
+			return fmt.Errorf("notify[%d]: references $%s; only %s* variables are expanded", i, name, NotifyEnvPrefix)
+		}
+		if n.MinSeverity != "" {
+			if _, err := ParseSeverity(n.MinSeverity); err != nil {
+				return fmt.Errorf("notify[%d]: min_severity: %w", i, err)
+			}
+		}
+		for _, t := range n.Types {
+			if t != "bug" && t != "enhancement" {
+				return fmt.Errorf("notify[%d]: unknown finding type %q (use bug or enhancement)", i, t)
+			}
+		}
+	}
+	return nil
+}
+
+// NotifyEnvPrefix starts the names of the variables notify urls and
+// secrets may reference. project.yaml is part of the repository, so it
+// must not be able to put other variables (say GITHUB_TOKEN in CI) into a
+// request to a host it chooses.
+const NotifyEnvPrefix = "DAI_NOTIFY_"
+
+// expandNotify replaces ${DAI_NOTIFY_*} references with their values and
+// leaves any other reference as it is.
+func expandNotify(s string) string {
+	return os.Expand(s, func(name string) string {
+		if strings.HasPrefix(name, NotifyEnvPrefix) {
+			return os.Getenv(name)
+		}
+		return "${" + name + "}"
+	})
+}
+
+// foreignNotifyVar returns the first variable s references that
+// expandNotify will not expand.
+func foreignNotifyVar(s string) string {
+	var foreign string
+	os.Expand(s, func(name string) string {
+		if foreign == "" && !strings.HasPrefix(name, NotifyEnvPrefix) {
+			foreign = name
+		}
+		return ""
+	})
+	return foreign
+}
+
+// notifyAll sends every sink the findings that pass its filter. Failures
+// are recorded per sink and do not fail the run.
+func notifyAll(ctx context.Context, opt Options, res *Result) {
+	for _, n := range opt.Notify {
+		name := n.Name
+		if name == "" {
+			name = n.Type
+		}
+		findings := notifyFindings(res.Findings, n)
+		if len(findings) == 0 {
+			res.Notifications = append(res.Notifications, Notification{Sink: name, Skipped: true})
+			continue
+		}
+		sink := notify.Sink{Type: n.Type, URL: expandNotify(n.URL), Secret: expandNotify(n.Secret)}
+		err := notify.Send(ctx, sink, notifySummary(opt, res, findings))
+		res.Notifications = append(res.Notifications, Notification{Sink: name, Err: err})
+	}
+}
+
+func notifyFindings(findings []Finding, n project.Notify) []Finding {
+	min := n.MinSeverity
+	if min == "" {
+		min = "low"
+	}
+	var out []Finding
+	for _, f := range AtLeast(findings, min) {
+		if len(n.Types) == 0 || slices.Contains(n.Types, f.Type) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func notifySummary(opt Options, res *Result, findings []Finding) notify.Summary {
+	s := notify.Summary{
+		Repo:   opt.Owner + "/" + opt.Repo,
+		Commit: res.Commit,
+		Scope:  res.Scope,
+		URL:    res.ReportURL(),
+		Counts: map[string]int{},
+		Total:  len(findings),
+	}
+	sorted := slices.Clone(findings)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return severityRank[sorted[i].Severity] > severityRank[sorted[j].Severity]
+	})
+	for _, f := range sorted {
+		sev := f.Severity
+		if sev == "" {
+			sev = "low"
+		}
+		s.Counts[sev]++
+		if len(s.Findings) < maxNotifyItems {
+			s.Findings = append(s.Findings, notify.Item{
+				Type:     f.Type,
+				Severity: f.Severity,
+				Title:    safeText(f.Title),
+				File:     f.File,
+				Lines:    linesText(f),
+			})
+		}
+	}
+	return s
+}
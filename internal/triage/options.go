@@ -1,24 +1,140 @@
 package triage
 
+import (
+	"fmt"
+
+	"github.com/gorankrgovic/dai/internal/gh"
+	"github.com/gorankrgovic/dai/internal/gitutil"
+	"github.com/gorankrgovic/dai/internal/project"
+	"github.com/gorankrgovic/dai/internal/tracker"
+)
+
 type Options struct {
 	Root         string
-	Owner        string
+	Provider     string // tracker.GitHub (default) or tracker.GitLab
+	Owner        string // owner or namespace (GitLab groups may nest)
 	Repo         string
-	GitHubToken  string
+	Token        string
+	GitHubTokens gh.TokenSource // GitHub App installation tokens; replaces Token
+	APIBase      string         // REST API root; empty means api.github.com
 	OpenAIKey    string
 	Model        string
 	Commit       string
+	Base         string // when set, diff Base..Commit instead of a single commit
+	Staged       bool   // diff the index against HEAD instead of a commit
+	PR           int    // triage this pull request through the API instead
 	IncludeExts  []string
 	MaxFileBytes int64
 	IgnoreFile   string
 	DryRun       bool
 	AlwaysOpen   bool
+	NewIssue     bool // skip the search for an existing issue to update
 	DiffContext  int
+	MergeMode    gitutil.MergeMode // how merge commits are diffed
+	AssignAuthor string            // AssignNone | AssignIssue | AssignMention
+	Publish      []string          // Publish* targets; empty means issue
+	FailOn       string            // severity that fails a check run or status (default high)
+	Jira         *project.Jira     // for PublishJira
+	JiraToken    string            // "email:api-token" (Cloud) or a personal access token
+	Notify       []project.Notify  // sinks notified after publishing
+	Validate     *project.Validate // check a generated fix must pass; nil skips it
 }
 
+// Result is what Run found and published. Commit, Scope and Findings are
+// set for every run, including dry runs.
 type Result struct {
+	Commit   string
+	Scope    string
+	Findings []Finding
+
 	URL     string
 	Number  int
 	Body    string
 	Skipped bool
+	// Updated is set when an existing issue was found and updated instead
+	// of opening a new one; NewFindings counts what it did not list yet.
+	Updated     bool
+	NewFindings int
+	CheckURL    string // check run, when published to checks
+	// CommitCommentURL is empty when there was nothing to comment.
+	CommitCommentURL string
+	// JiraTickets lists the tickets created; findings already filed are not
+	// filed again.
+	JiraTickets []JiraTicket
+	// Notifications has one entry per configured sink.
+	Notifications []Notification
+}
+
+// ReportURL links to the first thing published: the issue, check run,
+// commit comment or Jira ticket.
+func (r *Result) ReportURL() string {
+	for _, u := range []string{r.URL, r.CheckURL, r.CommitCommentURL} {
+		if u != "" {
+			return u
+		}
+	}
+	if len(r.JiraTickets) > 0 {
+		return r.JiraTickets[0].URL
+	}
+	return ""
+}
+
+func (o Options) tracker() (tracker.Tracker, error) {
+	return tracker.New(tracker.Config{
+		Provider:     o.Provider,
+		APIBase:      o.APIBase,
+		Token:        o.Token,
+		Namespace:    o.Owner,
+		Repo:         o.Repo,
+		GitHubTokens: o.GitHubTokens,
+	})
+}
+
+func (o Options) reviewer() (tracker.Reviewer, error) {
+	return tracker.NewReviewer(tracker.Config{
+		Provider:     o.Provider,
+		APIBase:      o.APIBase,
+		Token:        o.Token,
+		Namespace:    o.Owner,
+		Repo:         o.Repo,
+		GitHubTokens: o.GitHubTokens,
+	})
+}
+
+func (o Options) changeRequester() (tracker.ChangeRequester, error) {
+	return tracker.NewChangeRequester(tracker.Config{
+		Provider:     o.Provider,
+		APIBase:      o.APIBase,
+		Token:        o.Token,
+		Namespace:    o.Owner,
+		Repo:         o.Repo,
+		GitHubTokens: o.GitHubTokens,
+	})
+}
+
+// changeRequestName is how the provider refers to change request n.
+func (o Options) changeRequestName(n int) string {
+	if o.Provider == tracker.GitLab {
+		return fmt.Sprintf("merge request !%d", n)
+	}
+	return fmt.Sprintf("pull request #%d", n)
+}
+
+func (o Options) isGitHub() bool {
+	return o.Provider == "" || o.Provider == tracker.GitHub
+}
+
+// github returns a client for features only GitHub has.
+func (o Options) github(feature string) (*gh.Client, error) {
+	if !o.isGitHub() {
+		return nil, fmt.Errorf("%s is only supported on GitHub, not %s", feature, o.Provider)
+	}
+	return o.githubClient(), nil
+}
+
+func (o Options) githubClient() *gh.Client {
+	if o.GitHubTokens != nil {
+		return gh.NewClientWithTokenSource(o.APIBase, o.GitHubTokens)
+	}
+	return gh.NewClient(o.APIBase, o.Token)
 }
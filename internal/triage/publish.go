@@ -0,0 +1,165 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gorankrgovic/dai/internal/gh"
+)
+
+// Publish targets for Options.Publish.
+const (
+	PublishIssue         = "issue"
+	PublishChecks        = "checks"
+	PublishStatus        = "status"
+	PublishCommitComment = "commit-comment"
+	PublishJira          = "jira"
+)
+
+// publishTargets is also the order targets run in: the status links to
+// whatever was published before it.
+var publishTargets = []string{PublishIssue, PublishJira, PublishChecks, PublishCommitComment, PublishStatus}
+
+// CheckName is the name of the check run (and status context) dai reports.
+const CheckName = "dai/triage"
+
+// ParsePublish validates a comma-separated list of publish targets and
+// returns them in run order. An empty list means issue.
+func ParsePublish(csv string) ([]string, error) {
+	seen := map[string]bool{}
+	for _, t := range strings.Split(csv, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+		valid := false
+		for _, p := range publishTargets {
+			valid = valid || p == t
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown publish target %q (use %s)", t, strings.Join(publishTargets, ", "))
+		}
+		seen[t] = true
+	}
+	var out []string
+	for _, p := range publishTargets {
+		if seen[p] {
+			out = append(out, p)
+		}
+	}
+	if len(out) == 0 {
+		out = []string{PublishIssue}
+	}
+	return out, nil
+}
+
+// conclusion is failure when a finding meets opt.FailOn, neutral when
+// there are findings below it, success otherwise.
+func conclusion(findings []Finding, failOn string) string {
+	if failOn == "" {
+		failOn = "high"
+	}
+	switch {
+	case len(AtLeast(findings, failOn)) > 0:
+		return "failure"
+	case len(AtLeast(findings, "low")) > 0:
+		return "neutral"
+	}
+	return "success"
+}
+
+func publishCheck(ctx context.Context, client *gh.Client, opt Options, a *Analysis, title, body string) (string, error) {
+	if a.Commit == "" {
+		return "", fmt.Errorf("check runs need a commit (not available for staged changes)")
+	}
+	summary := body
+	if len(summary) > gh.MaxCheckSummary {
+		n := gh.MaxCheckSummary - 20
+		for n > 0 && !utf8.RuneStart(summary[n]) {
+			n-- // do not split a rune
+		}
+		summary = summary[:n] + "\n\n_(truncated)_"
+	}
+	out := gh.CheckOutput{Title: title, Summary: summary, Annotations: annotations(a.Findings)}
+	run, err := client.CreateCheckRun(ctx, opt.Owner, opt.Repo, CheckName, a.Commit, conclusion(a.Findings, opt.FailOn), out)
+	if errors.Is(err, gh.ErrForbidden) {
+		return "", fmt.Errorf("%w\nthe Checks API needs a GitHub App installation token; personal access tokens cannot create check runs", err)
+	}
+	if err != nil {
+		return "", err
+	}
+	return run.HTMLURL, nil
+}
+
+// annotations returns one annotation per finding with a resolved range.
+func annotations(findings []Finding) []gh.Annotation {
+	var out []gh.Annotation
+	for _, f := range findings {
+		if f.StartLine == 0 || (f.Type != "bug" && f.Type != "enhancement") {
+			continue
+		}
+		level := "notice"
+		switch {
+		case f.Type == "bug" && f.Severity == "high":
+			level = "failure"
+		case f.Type == "bug":
+			level = "warning"
+		}
+		msg := f.Details
+		if msg == "" {
+			msg = f.Title
+		}
+		out = append(out, gh.Annotation{
+			Path:      f.File,
+			StartLine: f.StartLine,
+			EndLine:   max(f.EndLine, f.StartLine),
+			Level:     level,
+			Title:     safeText(f.Title),
+			Message:   msg,
+		})
+	}
+	return out
+}
+
+func publishCommitComment(ctx context.Context, client *gh.Client, opt Options, a *Analysis, body string) (string, error) {
+	if a.Commit == "" {
+		return "", fmt.Errorf("commit comments need a commit (not available for staged changes)")
+	}
+	return client.CreateCommitComment(ctx, opt.Owner, opt.Repo, a.Commit, body)
+}
+
+// publishStatus sets the dai/triage commit status. It links to the first
+// thing published before it, if any.
+func publishStatus(ctx context.Context, client *gh.Client, opt Options, a *Analysis, res *Result) error {
+	if a.Commit == "" {
+		return fmt.Errorf("commit statuses need a commit (not available for staged changes)")
+	}
+	failOn := opt.FailOn
+	if failOn == "" {
+		failOn = "high"
+	}
+	state := "success"
+	if conclusion(a.Findings, failOn) == "failure" {
+		state = "failure"
+	}
+	return client.CreateStatus(ctx, opt.Owner, opt.Repo, a.Commit, state, CheckName, statusDescription(a.Findings, failOn), res.ReportURL())
+}
+
+func statusDescription(findings []Finding, failOn string) string {
+	var bugs, enh int
+	for _, f := range findings {
+		switch f.Type {
+		case "bug":
+			bugs++
+		case "enhancement":
+			enh++
+		}
+	}
+	if bugs+enh == 0 {
+		return "No findings"
+	}
+	return fmt.Sprintf("%d bug(s), %d suggestion(s); %d at %s severity or above", bugs, enh, len(AtLeast(findings, failOn)), failOn)
+}
@@ -0,0 +1,70 @@
+package triage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gorankrgovic/dai/internal/gh"
+	"github.com/gorankrgovic/dai/internal/gitutil"
+)
+
+// analyzePull feeds the files of pull request opt.PR, as reported by the
+// GitHub API, to fn. No local checkout is needed.
+func analyzePull(ctx context.Context, opt Options, a *Analysis, keep func(string) bool, fn func(gitutil.FileDiff) error) error {
+	client, err := opt.github("--pr")
+	if err != nil {
+		return err
+	}
+	pr, err := client.GetPullRequest(ctx, opt.Owner, opt.Repo, opt.PR)
+	if err != nil {
+		return err
+	}
+	a.Commit = pr.Head.SHA
+	a.Scope = fmt.Sprintf("pull request #%d (%s → %s)", pr.Number, pr.Head.Ref, pr.Base.Ref)
+
+	files := 0
+	err = client.ListPullRequestFiles(ctx, opt.Owner, opt.Repo, opt.PR, func(f gh.PullFile) error {
+		files++
+		if !keep(f.Filename) {
+			return nil
+		}
+		fd := pullFileDiff(f)
+		// without a patch, a file with changes was too large for the API;
+		// one without is binary or only renamed
+		if f.Patch == "" && f.Changes > 0 && fd.Status != gitutil.StatusDeleted {
+			a.TooLarge = append(a.TooLarge, f.Filename)
+			return nil
+		}
+		return fn(fd)
+	})
+	if err != nil {
+		return err
+	}
+	if files >= gh.MaxPullFiles {
+		a.Scope += fmt.Sprintf(", first %d files only", gh.MaxPullFiles)
+	}
+	return nil
+}
+
+func pullFileDiff(f gh.PullFile) gitutil.FileDiff {
+	fd := gitutil.FileDiff{
+		Path:    f.Filename,
+		OldPath: f.Filename,
+		Status:  gitutil.StatusModified,
+		Hunks:   gitutil.ParseHunks(f.Patch),
+	}
+	if f.PreviousFilename != "" {
+		fd.OldPath = f.PreviousFilename
+	}
+	switch f.Status {
+	case "added":
+		fd.Status = gitutil.StatusAdded
+	case "removed":
+		fd.Status = gitutil.StatusDeleted
+	case "renamed":
+		fd.Status = gitutil.StatusRenamed
+	case "copied":
+		fd.Status = gitutil.StatusCopied
+	}
+	return fd
+}
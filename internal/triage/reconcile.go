@@ -0,0 +1,188 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gorankrgovic/dai/internal/gitutil"
+	"github.com/gorankrgovic/dai/internal/tracker"
+)
+
+const (
+	// files up to this many lines are sent whole to the recheck, longer ones
+	// as a window around the reported lines
+	recheckWholeFileLines = 800
+	recheckWindow         = 120
+)
+
+// Recheck is the verdict on one finding of an issue.
+type Recheck struct {
+	Finding MetaFinding
+	Fixed   bool
+	Reason  string
+}
+
+// Reconciled is one open dai issue after its findings were re-checked.
+type Reconciled struct {
+	Number int
+	Title  string
+	URL    string
+	Checks []Recheck
+	Closed bool // every finding is fixed (or would be closed on a dry run)
+}
+
+// Reconcile re-checks the findings of every open dai issue against the
+// HEAD version of their files. Fixed findings get a comment; issues whose
+// findings are all fixed are closed. Nothing is written on a dry run.
+func Reconcile(ctx context.Context, opt Options) ([]Reconciled, error) {
+	head, err := gitutil.HeadCommit(opt.Root)
+	if err != nil {
+		return nil, fmt.Errorf("resolve HEAD: %w", err)
+	}
+	cat, err := gitutil.NewCatFile(ctx, opt.Root, opt.MaxFileBytes)
+	if err != nil {
+		return nil, err
+	}
+	defer cat.Close()
+
+	t, err := opt.tracker()
+	if err != nil {
+		return nil, err
+	}
+	var issues []tracker.Issue
+	err = t.ListOpenIssues(ctx, func(is tracker.Issue) bool {
+		if _, ok := ParseIssueMeta(is.Body); ok {
+			issues = append(issues, is)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Reconciled
+	for _, is := range issues {
+		meta, _ := ParseIssueMeta(is.Body)
+		r := Reconciled{Number: is.Number, Title: is.Title, URL: is.URL}
+		open, fixed := 0, 0
+		for _, f := range meta.Findings {
+			if meta.IsFixed(f.FP) {
+				continue
+			}
+			c := recheck(ctx, opt, cat, f)
+			r.Checks = append(r.Checks, c)
+			if c.Fixed {
+				fixed++
+			} else {
+				open++
+			}
+		}
+		if fixed == 0 {
+			out = append(out, r)
+			continue
+		}
+		r.Closed = open == 0
+		if !opt.DryRun {
+			if err := publishReconciled(ctx, t, is, meta, r, head); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func recheck(ctx context.Context, opt Options, cat *gitutil.CatFile, f MetaFinding) Recheck {
+	c := Recheck{Finding: f}
+	blob, err := cat.Read("HEAD", f.File)
+	if errors.Is(err, gitutil.ErrObjectNotFound) {
+		// a rename keeps the code: only a deletion fixes the finding
+		var renamed string
+		deleted := false
+		if f.Commit != "" && gitutil.HasCommit(opt.Root, f.Commit) {
+			renamed, deleted, _ = gitutil.FileFate(opt.Root, f.Commit, "HEAD", f.File)
+		}
+		switch {
+		case deleted:
+			c.Fixed, c.Reason = true, "the file no longer exists"
+			return c
+		case renamed == "":
+			c.Reason = "could not check: the file was moved or deleted"
+			return c
+		}
+		f.File = renamed // for the prompt; c keeps the reported path
+		blob, err = cat.Read("HEAD", renamed)
+	}
+	if err != nil || blob.Binary {
+		c.Reason = "could not read the file"
+		return c
+	}
+	// the model must not judge code it cannot see: "not present" would
+	// close the finding
+	if blob.Truncated {
+		c.Reason = "could not check: the file is too large"
+		return c
+	}
+	excerpt := numberedExcerpt(string(blob.Content), f.Start, f.End)
+	if excerpt == "" {
+		c.Reason = "could not check: the finding's lines are past the end of the file"
+		return c
+	}
+	present, reason, err := recheckFinding(ctx, opt.OpenAIKey, opt.Model, f, excerpt)
+	if err != nil {
+		c.Reason = "recheck failed: " + err.Error()
+		return c
+	}
+	c.Fixed, c.Reason = !present, reason
+	return c
+}
+
+// numberedExcerpt prefixes lines with their number; long files are cut to a
+// window around start..end.
+func numberedExcerpt(content string, start, end int) string {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	lo, hi := 1, len(lines)
+	if len(lines) > recheckWholeFileLines && start > 0 {
+		lo = max(1, start-recheckWindow)
+		hi = min(len(lines), max(end, start)+recheckWindow)
+	} else if len(lines) > recheckWholeFileLines {
+		hi = recheckWholeFileLines
+	}
+	var sb strings.Builder
+	for n := lo; n <= hi; n++ {
+		fmt.Fprintf(&sb, "%5d| %s\n", n, lines[n-1])
+	}
+	return sb.String()
+}
+
+func publishReconciled(ctx context.Context, t tracker.Tracker, is tracker.Issue, meta *IssueMeta, r Reconciled, head string) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Re-checked against `%.8s`:\n\n", head)
+	for _, c := range r.Checks {
+		mark := "⏳ still present"
+		if c.Fixed {
+			mark = "✅ fixed"
+			meta.Fixed = append(meta.Fixed, c.Finding.FP)
+		}
+		fmt.Fprintf(&sb, "- %s — **%s** (`%s`)", mark, safeText(c.Finding.Title), c.Finding.File)
+		if c.Reason != "" {
+			fmt.Fprintf(&sb, ": %s", safeText(c.Reason))
+		}
+		sb.WriteString("\n")
+	}
+	if r.Closed {
+		sb.WriteString("\nAll findings are fixed; closing.\n")
+	}
+	if _, err := t.Comment(ctx, is.Number, sb.String()); err != nil {
+		return err
+	}
+	if err := t.SetIssueBody(ctx, is.Number, withIssueMeta(is.Body, meta)); err != nil {
+		return err
+	}
+	if r.Closed {
+		return t.CloseIssue(ctx, is.Number)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package triage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Record is the local findings record (.dai/findings.json): findings dai
+// filed in systems without an issue marker to search for, keyed by
+// fingerprint, so reruns do not file them again.
+type Record struct {
+	Findings map[string]RecordEntry `json:"findings"`
+}
+
+type RecordEntry struct {
+	Commit string    `json:"commit,omitempty"`
+	File   string    `json:"file"`
+	Title  string    `json:"title"`
+	Jira   string    `json:"jira,omitempty"` // issue key
+	Filed  time.Time `json:"filed"`
+}
+
+func RecordPath(root string) string {
+	return filepath.Join(root, ".dai", "findings.json")
+}
+
+// LoadRecord reads the findings record; a missing file yields an empty one.
+func LoadRecord(root string) (*Record, error) {
+	r := &Record{Findings: map[string]RecordEntry{}}
+	b, err := os.ReadFile(RecordPath(root))
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, err
+	}
+	if r.Findings == nil {
+		r.Findings = map[string]RecordEntry{}
+	}
+	return r, nil
+}
+
+// Save writes the record through a temporary file so an interrupted run
+// cannot truncate it.
+func (r *Record) Save(root string) error {
+	p := RecordPath(root)
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
@@ -0,0 +1,234 @@
+package triage
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gorankrgovic/dai/internal/gitutil"
+	"github.com/gorankrgovic/dai/internal/tracker"
+)
+
+// Hidden markers that let a re-run recognize what dai posted before.
+const (
+	reviewMarker     = "<!-- dai:review -->"
+	supersededReview = reviewMarker + "\n_Superseded by a newer DAI review._"
+)
+
+var reFindingMarker = regexp.MustCompile(`<!-- dai:finding fp=([0-9a-f]+) -->`)
+
+func findingMarker(fp string) string {
+	return fmt.Sprintf("<!-- dai:finding fp=%s -->", fp)
+}
+
+// ReviewResult describes what Review posted (or would post on a dry run).
+type ReviewResult struct {
+	URL      string
+	Body     string                // review summary
+	Comments []tracker.LineComment // new inline comments
+	Updated  int                   // earlier dai comments whose text changed
+	Resolved int                   // earlier dai threads whose finding is gone
+}
+
+// Review triages change request number (a pull request, or a GitLab merge
+// request) and publishes the findings as one review: findings on changed
+// lines become inline comments, the rest go into the summary. Earlier dai
+// comments are matched by fingerprint and updated, or resolved when their
+// finding is gone.
+func Review(ctx context.Context, opt Options, number int) (*ReviewResult, error) {
+	rv, err := opt.reviewer()
+	if err != nil {
+		return nil, err
+	}
+	cr, err := rv.ChangeRequest(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+
+	if !gitutil.HasCommit(opt.Root, cr.HeadSHA) || !gitutil.HasCommit(opt.Root, cr.BaseSHA) {
+		refs := []string{cr.FetchRef, "refs/heads/" + cr.BaseRef}
+		if err := gitutil.Fetch(ctx, opt.Root, "origin", refs...); err != nil {
+			return nil, fmt.Errorf("fetch %s: %w", opt.changeRequestName(number), err)
+		}
+	}
+	base, err := gitutil.MergeBase(opt.Root, cr.BaseSHA, cr.HeadSHA)
+	if err != nil {
+		return nil, fmt.Errorf("merge base: %w", err)
+	}
+	opt.Commit, opt.Base, opt.Staged = cr.HeadSHA, base, false
+	a, err := Analyze(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	a.Scope = fmt.Sprintf("%s (%s → %s)", opt.changeRequestName(number), cr.HeadRef, cr.BaseRef)
+
+	notes, err := rv.Notes(ctx, cr)
+	if err != nil {
+		return nil, err
+	}
+	previous := map[string]tracker.Note{}
+	var summaries []tracker.Note
+	for _, n := range notes {
+		if n.Line == 0 {
+			if strings.Contains(n.Body, reviewMarker) && n.Body != supersededReview {
+				summaries = append(summaries, n)
+			}
+			continue
+		}
+		if m := reFindingMarker.FindStringSubmatch(n.Body); m != nil {
+			previous[m[1]] = n
+		}
+	}
+
+	res := &ReviewResult{}
+	var unanchored []Finding
+	current := map[string]bool{}
+	for _, f := range a.Findings {
+		if f.Type != "bug" && f.Type != "enhancement" {
+			continue
+		}
+		fp := Fingerprint(f)
+		current[fp] = true
+		if f.StartLine == 0 {
+			unanchored = append(unanchored, f)
+			continue
+		}
+		body := reviewCommentBody(f, fp, opt.AssignAuthor == AssignMention)
+		if prev, ok := previous[fp]; ok {
+			if strings.TrimSpace(prev.Body) != strings.TrimSpace(body) {
+				res.Updated++
+				if !opt.DryRun {
+					if err := rv.EditNote(ctx, cr, prev, body); err != nil {
+						return nil, err
+					}
+				}
+			}
+			if prev.Resolved && !opt.DryRun {
+				if err := rv.SetResolved(ctx, cr, prev, false); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		res.Comments = append(res.Comments, lineComment(f, body))
+	}
+	for fp, prev := range previous {
+		if current[fp] || prev.Resolved || prev.ThreadID == "" {
+			continue
+		}
+		res.Resolved++
+		if !opt.DryRun {
+			if err := rv.SetResolved(ctx, cr, prev, true); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	res.Body = reviewSummary(a, unanchored, res, opt.AssignAuthor == AssignMention)
+	if opt.DryRun {
+		return res, nil
+	}
+
+	res.URL, err = rv.SubmitReview(ctx, cr, res.Comments, func(rejected []tracker.LineComment) string {
+		if len(rejected) == 0 {
+			return res.Body
+		}
+		// comments on lines the server does not consider part of the
+		// diff are listed in the summary instead
+		refused := map[tracker.LineComment]bool{}
+		for _, c := range rejected {
+			refused[c] = true
+			for _, f := range a.Findings {
+				if f.File == c.Path && f.EndLine == c.Line {
+					unanchored = append(unanchored, f)
+				}
+			}
+		}
+		kept := res.Comments[:0]
+		for _, c := range res.Comments {
+			if !refused[c] {
+				kept = append(kept, c)
+			}
+		}
+		res.Comments = kept
+		res.Body = reviewSummary(a, unanchored, res, opt.AssignAuthor == AssignMention)
+		return res.Body
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// collapse earlier summaries so only the latest one carries the report
+	for _, n := range summaries {
+		if err := rv.EditNote(ctx, cr, n, supersededReview); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func lineComment(f Finding, body string) tracker.LineComment {
+	c := tracker.LineComment{Path: f.File, Body: body, Line: f.EndLine}
+	if f.EndLine > f.StartLine {
+		c.StartLine = f.StartLine
+	}
+	return c
+}
+
+func reviewCommentBody(f Finding, fp string, mention bool) string {
+	var sb strings.Builder
+	kind := "🐞 Bug"
+	if f.Type == "enhancement" {
+		kind = "✨ Suggestion"
+	}
+	fmt.Fprintf(&sb, "**%s", kind)
+	if f.Severity != "" {
+		fmt.Fprintf(&sb, " (%s)", strings.ToUpper(f.Severity))
+	}
+	fmt.Fprintf(&sb, ": %s**\n", safeText(f.Title))
+	if f.Details != "" {
+		fmt.Fprintf(&sb, "\n%s\n", f.Details)
+	}
+	if a := authorText(f, mention); a != "" {
+		fmt.Fprintf(&sb, "\n_Author: %s_\n", a)
+	}
+	sb.WriteString("\n" + findingMarker(fp))
+	return sb.String()
+}
+
+func reviewSummary(a *Analysis, unanchored []Finding, res *ReviewResult, mention bool) string {
+	var sb strings.Builder
+	sb.WriteString(reviewMarker + "\n")
+	fmt.Fprintf(&sb, "## DAI review for `%.8s`\n\n_Scope: %s._\n\n", a.Commit, a.Scope)
+	fmt.Fprintf(&sb, "%d new inline comment(s)", len(res.Comments))
+	if res.Updated > 0 {
+		fmt.Fprintf(&sb, ", %d updated", res.Updated)
+	}
+	if res.Resolved > 0 {
+		fmt.Fprintf(&sb, ", %d resolved", res.Resolved)
+	}
+	sb.WriteString(".\n")
+	if len(unanchored) > 0 {
+		fmt.Fprintf(&sb, "\n### Not on a changed line (%d)\n", len(unanchored))
+		for i, f := range unanchored {
+			fmt.Fprintf(&sb, "%d) **%s** — `%s`", i+1, safeText(f.Title), f.File)
+			if f.Severity != "" {
+				fmt.Fprintf(&sb, " (%s)", strings.ToUpper(f.Severity))
+			}
+			sb.WriteString("\n")
+			if l := linesText(f); l != "" {
+				fmt.Fprintf(&sb, "   - Lines: %s\n", l)
+			}
+			if au := authorText(f, mention); au != "" {
+				fmt.Fprintf(&sb, "   - Author: %s\n", au)
+			}
+			if f.Details != "" {
+				fmt.Fprintf(&sb, "   - Details: %s\n", f.Details)
+			}
+		}
+	}
+	sb.WriteString(fileChangesSection(a.NotAnalyzed))
+	sb.WriteString(tooLargeSection(a.TooLarge))
+	return sb.String()
+}
@@ -10,11 +10,127 @@ import (
 	"github.com/gorankrgovic/dai/internal/gh"
 	"github.com/gorankrgovic/dai/internal/gitutil"
 	"github.com/gorankrgovic/dai/internal/ignore"
+	"github.com/gorankrgovic/dai/internal/tracker"
 )
 
+// Analysis is the outcome of analyzing a diff, before anything is
+// published.
+type Analysis struct {
+	Commit      string // empty for staged changes
+	Scope       string // human-readable description of what was diffed
+	Findings    []Finding
+	NotAnalyzed []gitutil.FileDiff
+	TooLarge    []string // files whose patch the API would not return
+}
+
 func Run(ctx context.Context, opt Options) (*Result, error) {
+	a, err := Analyze(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	title, body, labels := summarize(report{
+		Commit:         a.Commit,
+		Scope:          a.Scope,
+		Findings:       a.Findings,
+		NotAnalyzed:    a.NotAnalyzed,
+		TooLarge:       a.TooLarge,
+		MentionAuthors: opt.AssignAuthor == AssignMention,
+	})
+	res := &Result{Commit: a.Commit, Scope: a.Scope, Findings: a.Findings, Body: body}
+	if opt.DryRun {
+		return res, nil
+	}
+
+	publish := opt.Publish
+	if len(publish) == 0 {
+		publish = []string{PublishIssue}
+	}
+	for _, target := range publish {
+		var client *gh.Client
+		if target != PublishIssue && target != PublishJira {
+			c, err := opt.github("--publish " + target)
+			if err != nil {
+				return nil, err
+			}
+			client = c
+		}
+		switch target {
+		case PublishChecks:
+			url, err := publishCheck(ctx, client, opt, a, title, body)
+			if err != nil {
+				return nil, fmt.Errorf("publish check run: %w", err)
+			}
+			res.CheckURL = url
+		case PublishIssue:
+			t, err := opt.tracker()
+			if err != nil {
+				return nil, err
+			}
+			if err := publishIssue(ctx, t, opt, a, title, body, labels, res); err != nil {
+				return nil, err
+			}
+		case PublishJira:
+			if err := publishJira(ctx, opt, res); err != nil {
+				return nil, err
+			}
+		case PublishCommitComment:
+			if len(a.Findings) == 0 && !opt.AlwaysOpen {
+				continue
+			}
+			url, err := publishCommitComment(ctx, client, opt, a, body)
+			if err != nil {
+				return nil, err
+			}
+			res.CommitCommentURL = url
+		case PublishStatus:
+			if err := publishStatus(ctx, client, opt, a, res); err != nil {
+				return nil, err
+			}
+		}
+	}
+	notifyAll(ctx, opt, res)
+	return res, nil
+}
+
+// publishIssue opens an issue with the findings, or updates the open dai
+// issue that already covers them.
+func publishIssue(ctx context.Context, t tracker.Tracker, opt Options, a *Analysis, title, body string, labels []string, res *Result) error {
+	if len(a.Findings) == 0 && !opt.AlwaysOpen {
+		res.Skipped = true
+		return nil
+	}
+	meta := newIssueMeta(a.Commit, a.Findings)
+	if !opt.NewIssue {
+		issue, prev, err := findTriageIssue(ctx, t, meta)
+		if err != nil {
+			return fmt.Errorf("search existing issues: %w", err)
+		}
+		if issue != nil {
+			return updateTriageIssue(ctx, t, opt, a, issue, prev, meta, res)
+		}
+	}
+	body = withIssueMeta(body, meta)
+
+	if err := t.EnsureLabels(ctx, labels); err != nil {
+		return fmt.Errorf("ensure labels: %w", err)
+	}
+	var assign []string
+	if opt.AssignAuthor == AssignIssue {
+		assign = assignees(a.Findings)
+	}
+	issue, err := t.CreateIssue(ctx, tracker.NewIssue{Title: title, Body: body, Labels: labels, Assignees: assign})
+	if err != nil {
+		return fmt.Errorf("create issue: %w", err)
+	}
+	res.URL, res.Number = issue.URL, issue.Number
+	return nil
+}
+
+// Analyze diffs the selected changes (a commit, a range or the index) and
+// asks the model about every file.
+func Analyze(ctx context.Context, opt Options) (*Analysis, error) {
 	commit := strings.TrimSpace(opt.Commit)
-	if commit == "" {
+	if commit == "" && !opt.Staged && opt.PR == 0 {
 		h, err := gitutil.HeadCommit(opt.Root)
 		if err != nil {
 			return nil, fmt.Errorf("resolve HEAD: %w", err)
@@ -25,66 +141,91 @@ func Run(ctx context.Context, opt Options) (*Result, error) {
 	ign, _ := ignore.Load(opt.IgnoreFile)
 
 	// --- DIFF HUNKS ---
-	fileDiffs, err := gitutil.DiffHunks(opt.Root, commit, opt.DiffContext)
-	if err != nil {
-		return nil, fmt.Errorf("diff hunks: %w", err)
+	// files are filtered by git (extensions) and by the parser (.daiignore)
+	// before their hunks are read, then analyzed one at a time as they stream
+	dopt := gitutil.DiffOptions{
+		Context:   opt.DiffContext,
+		Pathspecs: gitutil.ExtPathspecs(opt.IncludeExts),
+		Keep: func(path string) bool {
+			if !hasAllowedExt(path, opt.IncludeExts) {
+				return false
+			}
+			return ign == nil || !ign.MatchesPath(path)
+		},
+		MaxFileBytes: opt.MaxFileBytes,
 	}
 
-	filtered := make([]gitutil.FileDiff, 0, len(fileDiffs))
-	for _, fd := range fileDiffs {
+	a := &Analysis{Commit: commit}
+	analyze := func(fd gitutil.FileDiff) error {
 		if fd.Binary {
-			continue
-		}
-		if !hasAllowedExt(fd.Path, opt.IncludeExts) {
-			continue
+			return nil
 		}
-		if ign != nil && ign.MatchesPath(fd.Path) {
-			continue
+		// deleted files and pure renames carry no new code to review
+		if fd.Status == gitutil.StatusDeleted || fd.PureRename() {
+			fd.Hunks = nil
+			a.NotAnalyzed = append(a.NotAnalyzed, fd)
+			return nil
 		}
 		if len(fd.Hunks) == 0 {
-			continue
+			return nil
 		}
-		filtered = append(filtered, fd)
-	}
-
-	findings := make([]Finding, 0, len(filtered))
-	for _, fd := range filtered {
-		blocks := make([]string, 0, len(fd.Hunks))
-		for _, h := range fd.Hunks {
-			var sb strings.Builder
-			sb.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", h.OldStart, h.OldLines, h.NewStart, h.NewLines))
-			for _, ln := range h.Lines {
-				// leave i '-' i ' ' i '+' lines — model needs minimal context,
-				// biggest focus is on '+'
-				sb.WriteString(ln)
-				sb.WriteString("\n")
-			}
-			blocks = append(blocks, sb.String())
-		}
-		ff, err := analyzeDiff(ctx, opt.OpenAIKey, opt.Model, fd.Path, blocks)
+		ff, err := analyzeDiff(ctx, opt.OpenAIKey, opt.Model, fd, hunkBlocks(fd))
 		if err != nil {
 			// non-fatal:
-			continue
+			return nil
 		}
-		findings = append(findings, ff)
+		resolveLines(&ff, fd)
+		a.Findings = append(a.Findings, ff)
+		return nil
 	}
 
-	title, body, labels := summarize(commit, findings)
-	if opt.DryRun {
-		return &Result{Body: body}, nil
+	var err error
+	switch {
+	case opt.PR > 0:
+		err = analyzePull(ctx, opt, a, dopt.Keep, analyze)
+	case opt.Staged:
+		a.Scope = "staged changes"
+		err = gitutil.StreamStagedDiff(ctx, opt.Root, dopt, analyze)
+	case strings.TrimSpace(opt.Base) != "":
+		a.Scope = fmt.Sprintf("changes %.8s..%.8s", opt.Base, commit)
+		err = gitutil.StreamRangeDiff(ctx, opt.Root, opt.Base, commit, dopt, analyze)
+	default:
+		parents, perr := gitutil.Parents(opt.Root, commit)
+		if perr != nil {
+			return nil, fmt.Errorf("resolve parents: %w", perr)
+		}
+		if len(parents) > 1 {
+			a.Scope = mergeScope(opt.MergeMode)
+			err = gitutil.StreamMergeDiff(ctx, opt.Root, commit, opt.MergeMode, dopt, analyze)
+		} else {
+			err = gitutil.StreamDiff(ctx, opt.Root, commit, dopt, analyze)
+		}
 	}
-	if len(findings) == 0 && !opt.AlwaysOpen {
-		return &Result{Body: body, Skipped: true}, nil
+	if err != nil {
+		return nil, fmt.Errorf("diff hunks: %w", err)
 	}
 
-	if err := gh.EnsureLabels(ctx, opt.Owner, opt.Repo, opt.GitHubToken, labels); err != nil {
-		return nil, fmt.Errorf("ensure labels: %w", err)
+	// staged lines have no commit to blame yet, remote PRs no local history
+	if !opt.Staged && opt.PR == 0 {
+		attributeAuthors(ctx, opt, commit, a.Findings)
 	}
-	url, num, err := gh.CreateIssue(ctx, opt.Owner, opt.Repo, opt.GitHubToken, title, body, labels)
-	if err != nil {
-		return nil, fmt.Errorf("create issue: %w", err)
+	return a, nil
+}
+
+func hunkBlocks(fd gitutil.FileDiff) []string {
+	blocks := make([]string, 0, len(fd.Hunks))
+	for _, h := range fd.Hunks {
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", h.OldStart, h.OldLines, h.NewStart, h.NewLines))
+		for _, ln := range h.Lines {
+			// leave i '-' i ' ' i '+' lines — model needs minimal context,
+			// biggest focus is on '+'
+			sb.WriteString(ln)
+			sb.WriteString("\n")
+		}
+		blocks = append(blocks, sb.String())
 	}
-	return &Result{URL: url, Number: num, Body: body}, nil
+	return blocks
 }
 
 func hasAllowedExt(path string, exts []string) bool {
@@ -105,10 +246,37 @@ func hasAllowedExt(path string, exts []string) bool {
 	return false
 }
 
-func summarize(commit string, findings []Finding) (title, body string, labels []string) {
+func mergeScope(mode gitutil.MergeMode) string {
+	switch mode {
+	case gitutil.MergeConflicts:
+		return "merge commit — conflict resolutions only"
+	case gitutil.MergeEvil:
+		return "merge commit — changes present in neither parent (evil merge)"
+	default:
+		return "merge commit — diff against first parent"
+	}
+}
+
+// report is everything summarize renders into the issue.
+type report struct {
+	Commit         string
+	Scope          string // non-empty for merge commits
+	Findings       []Finding
+	NotAnalyzed    []gitutil.FileDiff
+	TooLarge       []string
+	MentionAuthors bool
+}
+
+func summarize(r report) (title, body string, labels []string) {
+	commit, findings := r.Commit, r.Findings
+	header := fmt.Sprintf("Automated triage for commit `%s` at %s\n\n", commit, time.Now().Format(time.RFC3339))
+	if r.Scope != "" {
+		header += fmt.Sprintf("_Scope: %s._\n\n", r.Scope)
+	}
 	if len(findings) == 0 {
 		title = fmt.Sprintf("DAI Triage: commit %.8s (no candidate findings)", commit)
-		body = fmt.Sprintf("Automated triage for commit `%s` at %s\n\n_No findings from diff hunks._\n", commit, time.Now().Format(time.RFC3339))
+		body = header + "_No findings from diff hunks._\n"
+		body += fileChangesSection(r.NotAnalyzed) + tooLargeSection(r.TooLarge)
 		labels = []string{"question"}
 		return
 	}
@@ -128,7 +296,7 @@ func summarize(commit string, findings []Finding) (title, body string, labels []
 	})
 
 	var sb strings.Builder
-	fmt.Fprintf(&sb, "Automated triage for commit `%s` at %s\n\n", commit, time.Now().Format(time.RFC3339))
+	sb.WriteString(header)
 	if len(bugs) > 0 {
 		fmt.Fprintf(&sb, "## 🐞 Bugs (%d)\n", len(bugs))
 		for i, f := range bugs {
@@ -136,8 +304,11 @@ func summarize(commit string, findings []Finding) (title, body string, labels []
 			if f.Severity != "" {
 				fmt.Fprintf(&sb, "   - Severity: %s\n", strings.ToUpper(f.Severity))
 			}
-			if f.LineHints != "" {
-				fmt.Fprintf(&sb, "   - Lines: %s\n", f.LineHints)
+			if l := linesText(f); l != "" {
+				fmt.Fprintf(&sb, "   - Lines: %s\n", l)
+			}
+			if a := authorText(f, r.MentionAuthors); a != "" {
+				fmt.Fprintf(&sb, "   - Author: %s\n", a)
 			}
 			if f.Details != "" {
 				fmt.Fprintf(&sb, "   - Details: %s\n", f.Details)
@@ -149,12 +320,17 @@ func summarize(commit string, findings []Finding) (title, body string, labels []
 		fmt.Fprintf(&sb, "## ✨ Enhancements / Suggestions (%d)\n", len(enh))
 		for i, f := range enh {
 			fmt.Fprintf(&sb, "%d) **%s** — `%s`\n", i+1, safeText(f.Title), f.File)
+			if a := authorText(f, r.MentionAuthors); a != "" {
+				fmt.Fprintf(&sb, "   - Author: %s\n", a)
+			}
 			if f.Details != "" {
 				fmt.Fprintf(&sb, "   - Details: %s\n", f.Details)
 			}
 		}
 		fmt.Fprintln(&sb)
 	}
+	sb.WriteString(fileChangesSection(r.NotAnalyzed))
+	sb.WriteString(tooLargeSection(r.TooLarge))
 	labels = nil
 	if len(bugs) > 0 {
 		labels = append(labels, "bug")
@@ -169,6 +345,56 @@ func summarize(commit string, findings []Finding) (title, body string, labels []
 	return title, sb.String(), labels
 }
 
+// linesText prefers the resolved range and falls back to the model's hints.
+func linesText(f Finding) string {
+	switch {
+	case f.StartLine > 0 && f.EndLine > f.StartLine:
+		return fmt.Sprintf("%d-%d", f.StartLine, f.EndLine)
+	case f.StartLine > 0:
+		return fmt.Sprintf("%d", f.StartLine)
+	}
+	return f.LineHints
+}
+
+// fileChangesSection lists deleted and renamed files that were not sent to
+// the model, so reviewers still see them in the issue.
+func fileChangesSection(fds []gitutil.FileDiff) string {
+	if len(fds) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\n## 📁 Not analyzed (%d)\n", len(fds))
+	for _, fd := range fds {
+		switch fd.Status {
+		case gitutil.StatusDeleted:
+			fmt.Fprintf(&sb, "- `%s` — deleted\n", fd.Path)
+		default:
+			fmt.Fprintf(&sb, "- `%s` → `%s` — %s", fd.OldPath, fd.Path, fd.Status)
+			if fd.Similarity > 0 {
+				fmt.Fprintf(&sb, " (%d%% similar)", fd.Similarity)
+			}
+			if fd.ModeChanged() {
+				fmt.Fprintf(&sb, ", mode %s → %s", fd.OldMode, fd.NewMode)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// tooLargeSection lists files GitHub returned without a patch.
+func tooLargeSection(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\n## 📦 Too large to analyze (%d)\n", len(paths))
+	for _, p := range paths {
+		fmt.Fprintf(&sb, "- `%s` — diff too large for the GitHub API\n", p)
+	}
+	return sb.String()
+}
+
 func safeText(s string) string {
 	return strings.TrimSpace(strings.ReplaceAll(s, "\n", " "))
 }
@@ -0,0 +1,37 @@
+package triage
+
+import (
+	"fmt"
+	"strings"
+)
+
+var severityRank = map[string]int{"low": 1, "medium": 2, "high": 3}
+
+// ParseSeverity validates a severity threshold (low|medium|high).
+func ParseSeverity(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if _, ok := severityRank[s]; !ok {
+		return "", fmt.Errorf("unknown severity %q (use low, medium or high)", s)
+	}
+	return s, nil
+}
+
+// AtLeast returns the reported findings whose severity meets threshold.
+// Findings without a severity count as low.
+func AtLeast(findings []Finding, threshold string) []Finding {
+	min := severityRank[strings.ToLower(threshold)]
+	var out []Finding
+	for _, f := range findings {
+		if f.Type != "bug" && f.Type != "enhancement" {
+			continue
+		}
+		rank := severityRank[f.Severity]
+		if rank == 0 {
+			rank = severityRank["low"]
+		}
+		if rank >= min {
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -8,4 +8,14 @@ type Finding struct {
 	Details   string
 	Severity  string // low|medium|high
 	LineHints string
+
+	// StartLine/EndLine is the resolved range in the new version of File
+	// (0 when the model's hints could not be matched to the diff).
+	StartLine int
+	EndLine   int
+
+	// Author of the flagged lines, from git blame.
+	AuthorName  string
+	AuthorEmail string
+	AuthorLogin string // GitHub login, when known
 }
@@ -0,0 +1,137 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/gorankrgovic/dai/internal/project"
+)
+
+const (
+	defaultValidateTimeout = 10 * time.Minute
+	// the tail of the output that is kept as evidence
+	validateOutputBytes = 16 * 1024
+)
+
+// ErrValidationFailed is returned when a generated fix does not pass the
+// project's validation command; the fix is then not proposed.
+var ErrValidationFailed = errors.New("validation failed")
+
+// Validation is the outcome of running the validation command on a fix.
+type Validation struct {
+	Command   string
+	Passed    bool
+	TimedOut  bool
+	ExitCode  int
+	Duration  time.Duration
+	Output    string // combined stdout and stderr, cut to its tail
+	Truncated bool
+}
+
+// runValidation runs cfg.Command in dir, which holds the patched tree.
+func runValidation(ctx context.Context, dir string, cfg *project.Validate) (*Validation, error) {
+	command := strings.TrimSpace(cfg.Command)
+	if command == "" {
+		return nil, errors.New("validate.command is empty in .dai/project.yaml")
+	}
+	timeout := defaultValidateTimeout
+	if cfg.Timeout != "" {
+		d, err := time.ParseDuration(cfg.Timeout)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("validate.timeout %q in .dai/project.yaml: not a duration like 5m", cfg.Timeout)
+		}
+		timeout = d
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "DAI_VALIDATE=1")
+	out := &tailWriter{max: validateOutputBytes}
+	cmd.Stdout, cmd.Stderr = out, out
+	// children that outlive a killed shell must not keep Wait waiting
+	cmd.WaitDelay = 5 * time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	v := &Validation{
+		Command:   command,
+		Duration:  time.Since(start).Round(time.Millisecond),
+		Output:    string(out.buf),
+		Truncated: out.cut,
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		v.TimedOut, v.ExitCode, v.Duration = true, -1, timeout
+	case errors.As(err, &exitErr):
+		v.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		return nil, fmt.Errorf("run validation: %w", err)
+	default:
+		v.Passed = true
+	}
+	return v, nil
+}
+
+// Summary is a one-line verdict.
+func (v *Validation) Summary() string {
+	switch {
+	case v.Passed:
+		return fmt.Sprintf("`%s` passed in %s", v.Command, v.Duration)
+	case v.TimedOut:
+		return fmt.Sprintf("`%s` timed out after %s", v.Command, v.Duration)
+	default:
+		return fmt.Sprintf("`%s` failed with exit code %d after %s", v.Command, v.ExitCode, v.Duration)
+	}
+}
+
+// Markdown renders the verdict and the output as a collapsible block.
+func (v *Validation) Markdown() string {
+	var sb strings.Builder
+	mark := "✅"
+	if !v.Passed {
+		mark = "❌"
+	}
+	fmt.Fprintf(&sb, "%s Validation: %s\n\n", mark, v.Summary())
+	// a fence longer than any backtick run in the output
+	fence := "```"
+	for strings.Contains(v.Output, fence) {
+		fence += "`"
+	}
+	sb.WriteString("<details><summary>Output</summary>\n\n" + fence + "\n")
+	if v.Truncated {
+		sb.WriteString("[…]\n")
+	}
+	sb.WriteString(strings.TrimRight(v.Output, "\n"))
+	sb.WriteString("\n" + fence + "\n\n</details>\n")
+	return sb.String()
+}
+
+// tailWriter keeps the last max bytes written to it.
+type tailWriter struct {
+	max int
+	buf []byte
+	cut bool
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	if over := len(w.buf) - w.max; over > 0 {
+		w.buf = append(w.buf[:0], w.buf[over:]...)
+		w.cut = true
+	}
+	return len(p), nil
+}